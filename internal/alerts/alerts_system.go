@@ -14,6 +14,16 @@ import (
 	"github.com/spf13/cast"
 )
 
+// hasSNMPReading reports whether an SNMP system reported the dashboard summary with the
+// given DashboardHas* bit. Monitors that flag their summaries report zero and negative
+// readings as well; for older ones that don't, summaries below 1 are taken as missing.
+func hasSNMPReading(info *system.Info, value float64, bit uint64) bool {
+	if info.DashboardPresent != 0 {
+		return info.HasDashboard(bit)
+	}
+	return value >= 1
+}
+
 func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *system.CombinedData) error {
 	alertRecords, err := am.hub.FindAllRecords("alerts",
 		dbx.NewExp("system={:system} AND name!='Status'", dbx.Params{"system": systemRecord.Id}),
@@ -66,43 +76,43 @@ func (am *AlertManager) HandleSystemAlerts(systemRecord *core.Record, data *syst
 			unit = ""
 		// SNMP Sensor Alerts
 		case "SNMPTemperature":
-			if data.Info.DashboardTemp < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardTemp, system.DashboardHasTemp) {
 				continue
 			}
 			val = data.Info.DashboardTemp
 			unit = "°C"
 		case "SNMPHumidity":
-			if data.Info.DashboardHumidity < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardHumidity, system.DashboardHasHumidity) {
 				continue
 			}
 			val = data.Info.DashboardHumidity
 			unit = "%"
 		case "SNMPCO2":
-			if data.Info.DashboardCO2 < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardCO2, system.DashboardHasCO2) {
 				continue
 			}
 			val = data.Info.DashboardCO2
 			unit = " ppm"
 		case "SNMPPressure":
-			if data.Info.DashboardPressure < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardPressure, system.DashboardHasPressure) {
 				continue
 			}
 			val = data.Info.DashboardPressure
 			unit = " hPa"
 		case "SNMPPM25":
-			if data.Info.DashboardPM25 < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardPM25, system.DashboardHasPM25) {
 				continue
 			}
 			val = data.Info.DashboardPM25
 			unit = " µg/m³"
 		case "SNMPPM10":
-			if data.Info.DashboardPM10 < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardPM10, system.DashboardHasPM10) {
 				continue
 			}
 			val = data.Info.DashboardPM10
 			unit = " µg/m³"
 		case "SNMPVOC":
-			if data.Info.DashboardVOC < 1 {
+			if !hasSNMPReading(&data.Info, data.Info.DashboardVOC, system.DashboardHasVOC) {
 				continue
 			}
 			val = data.Info.DashboardVOC
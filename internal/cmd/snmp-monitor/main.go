@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/henrygd/beszel/internal/snmpmonitor"
 )
@@ -19,6 +21,26 @@ func main() {
 		log.Fatal("Failed to create container agent:", err)
 	}
 
+	// Stop cleanly on SIGINT/SIGTERM so pollers flush and the web server shuts down
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		log.Printf("Received %v, shutting down", sig)
+		agent.Stop()
+	}()
+
+	// Reload the config file on SIGHUP, keeping the current config if the file is invalid
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			if err := agent.Reload(); err != nil {
+				log.Printf("Config reload failed, keeping current configuration: %v", err)
+			}
+		}
+	}()
+
 	log.Println("Starting SNMP monitor...")
 	if err := agent.Run(); err != nil {
 		log.Fatal("SNMP monitor failed:", err)
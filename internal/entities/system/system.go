@@ -48,6 +48,15 @@ type Stats struct {
 	PM25     map[string]float64 `json:"pm25,omitempty" cbor:"34,keyasint,omitempty"`
 	PM10     map[string]float64 `json:"pm10,omitempty" cbor:"35,keyasint,omitempty"`
 	VOC      map[string]float64 `json:"voc,omitempty" cbor:"36,keyasint,omitempty"`
+	// --- Power telemetry for SNMP agents ---
+	Voltage map[string]float64 `json:"volt,omitempty" cbor:"37,keyasint,omitempty"`
+	Current map[string]float64 `json:"cur,omitempty" cbor:"38,keyasint,omitempty"`
+	Power   map[string]float64 `json:"pw,omitempty" cbor:"39,keyasint,omitempty"`
+	Fan     map[string]float64 `json:"fan,omitempty" cbor:"40,keyasint,omitempty"`
+	// --- UPS telemetry for SNMP agents ---
+	BatteryCharge map[string]float64 `json:"batc,omitempty" cbor:"41,keyasint,omitempty"`
+	Runtime       map[string]float64 `json:"rt,omitempty" cbor:"42,keyasint,omitempty"`
+	UPSLoad       map[string]float64 `json:"upsl,omitempty" cbor:"43,keyasint,omitempty"`
 }
 
 type GPUData struct {
@@ -121,6 +130,43 @@ type Info struct {
 	DashboardPM25     float64 `json:"dpm25,omitempty" cbor:"24,keyasint,omitempty"`
 	DashboardPM10     float64 `json:"dpm10,omitempty" cbor:"25,keyasint,omitempty"`
 	DashboardVOC      float64 `json:"dvoc,omitempty" cbor:"26,keyasint,omitempty"`
+	// Free-form details reported by SNMP agents
+	ExtraInfo map[string]string `json:"ei,omitempty" cbor:"27,keyasint,omitempty"`
+	// Dashboard power summaries for SNMP agents
+	DashboardVoltage float64 `json:"dvolt,omitempty" cbor:"28,keyasint,omitempty"`
+	DashboardCurrent float64 `json:"dcur,omitempty" cbor:"29,keyasint,omitempty"`
+	DashboardPower   float64 `json:"dpw,omitempty" cbor:"30,keyasint,omitempty"`
+	DashboardFan     float64 `json:"dfan,omitempty" cbor:"31,keyasint,omitempty"`
+	// Dashboard UPS summaries for SNMP agents: the lowest battery charge and runtime, the highest load
+	DashboardBattery float64 `json:"dbatc,omitempty" cbor:"32,keyasint,omitempty"`
+	DashboardRuntime float64 `json:"drt,omitempty" cbor:"33,keyasint,omitempty"`
+	DashboardUPSLoad float64 `json:"dupsl,omitempty" cbor:"34,keyasint,omitempty"`
+	// Bit set of the dashboard summaries above that were reported (see DashboardHas*),
+	// since omitempty drops a summary of zero just like a missing one
+	DashboardPresent uint64 `json:"dset,omitempty" cbor:"35,keyasint,omitempty"`
+}
+
+// Bits of Info.DashboardPresent, one per dashboard summary
+const (
+	DashboardHasTemp uint64 = 1 << iota
+	DashboardHasHumidity
+	DashboardHasCO2
+	DashboardHasPressure
+	DashboardHasPM25
+	DashboardHasPM10
+	DashboardHasVOC
+	DashboardHasVoltage
+	DashboardHasCurrent
+	DashboardHasPower
+	DashboardHasFan
+	DashboardHasBattery
+	DashboardHasRuntime
+	DashboardHasUPSLoad
+)
+
+// HasDashboard reports whether the dashboard summary for the given DashboardHas* bit was reported
+func (i *Info) HasDashboard(bit uint64) bool {
+	return i.DashboardPresent&bit != 0
 }
 
 // Final data structure to return to the hub
@@ -128,4 +174,6 @@ type CombinedData struct {
 	Stats      Stats              `json:"stats" cbor:"0,keyasint"`
 	Info       Info               `json:"info" cbor:"1,keyasint"`
 	Containers []*container.Stats `json:"container" cbor:"2,keyasint"`
+	// Timestamp is when the data was collected, in Unix milliseconds. Zero if the agent doesn't report it.
+	Timestamp int64 `json:"ts,omitempty" cbor:"3,keyasint,omitempty"`
 }
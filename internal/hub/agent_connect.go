@@ -3,6 +3,7 @@ package hub
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 	"strings"
@@ -48,6 +49,33 @@ func (tm *tokenMap) GetMap() *expirymap.ExpiryMap[string] {
 	return tm.store
 }
 
+// configureHandshake applies fingerprint handshake and payload age settings from the environment.
+// Durations use Go syntax, e.g. HANDSHAKE_TIMEOUT=15s.
+func configureHandshake() {
+	var cfg ws.HandshakeConfig
+	parse := func(key string, dst *time.Duration) {
+		if value, exists := GetEnv(key); exists {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				log.Printf("Invalid %s %q: %v", key, value, err)
+				return
+			}
+			*dst = d
+		}
+	}
+	parse("HANDSHAKE_TIMEOUT", &cfg.Timeout)
+	parse("FINGERPRINT_TIMEOUT", &cfg.SignedTimeout)
+	parse("FINGERPRINT_FALLBACK_TIMEOUT", &cfg.UnsignedTimeout)
+	if requireSignature, _ := GetEnv("REQUIRE_AGENT_SIGNATURE"); requireSignature == "true" {
+		cfg.DisableUnsignedFallback = true
+	}
+	ws.SetHandshakeConfig(cfg)
+
+	var maxPayloadAge time.Duration
+	parse("MAX_PAYLOAD_AGE", &maxPayloadAge)
+	ws.SetMaxPayloadAge(maxPayloadAge)
+}
+
 // handleAgentConnect is the HTTP handler for an agent's connection request.
 func (h *Hub) handleAgentConnect(e *core.RequestEvent) error {
 	agentRequest := agentConnectRequest{req: e.Request, res: e.Response, hub: h}
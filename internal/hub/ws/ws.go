@@ -20,6 +20,55 @@ const (
 	deadline = 70 * time.Second
 )
 
+// HandshakeConfig controls how long the hub waits for an agent during the fingerprint handshake.
+type HandshakeConfig struct {
+	// SignedTimeout is how long to wait for a response to the signed fingerprint request.
+	SignedTimeout time.Duration
+	// UnsignedTimeout is how long to wait for a response to the unsigned fallback request used by SNMP monitors.
+	UnsignedTimeout time.Duration
+	// Timeout caps the whole handshake, including the fallback. Zero means no overall limit.
+	Timeout time.Duration
+	// DisableUnsignedFallback makes signature verification mandatory by never retrying without a signature.
+	DisableUnsignedFallback bool
+}
+
+var handshakeConfig = HandshakeConfig{
+	SignedTimeout:   5 * time.Second,
+	UnsignedTimeout: 10 * time.Second,
+}
+
+// SetHandshakeConfig sets the fingerprint handshake timeouts. Zero per-request timeouts keep their defaults.
+func SetHandshakeConfig(cfg HandshakeConfig) {
+	if cfg.SignedTimeout <= 0 {
+		cfg.SignedTimeout = 5 * time.Second
+	}
+	if cfg.UnsignedTimeout <= 0 {
+		cfg.UnsignedTimeout = 10 * time.Second
+	}
+	handshakeConfig = cfg
+}
+
+// ErrStalePayload is returned when an agent reports data older than the configured maximum payload age.
+var ErrStalePayload = errors.New("agent data is older than the maximum payload age")
+
+// maxPayloadAge is the oldest collection timestamp accepted from an agent. Zero disables the check.
+var maxPayloadAge time.Duration
+
+// SetMaxPayloadAge sets the maximum age of agent data. Payloads without a timestamp are always accepted.
+func SetMaxPayloadAge(age time.Duration) {
+	maxPayloadAge = age
+}
+
+// handshakeWait returns how long to wait for a handshake response, limited by the overall handshake deadline.
+func handshakeWait(timeout time.Duration, handshakeDeadline time.Time) time.Duration {
+	if !handshakeDeadline.IsZero() {
+		if remaining := time.Until(handshakeDeadline); remaining < timeout {
+			return max(remaining, 0)
+		}
+	}
+	return timeout
+}
+
 // Handler implements the WebSocket event handler for agent connections.
 type Handler struct {
 	gws.BuiltinEventHandler
@@ -219,6 +268,12 @@ func (ws *WsConn) RequestSystemData(data *system.CombinedData) error {
 	} else {
 		fmt.Printf("[DEBUG] RequestSystemData: Successfully unmarshaled system data\n")
 	}
+	if err == nil && maxPayloadAge > 0 && tmp.Timestamp > 0 {
+		if age := time.Since(time.UnixMilli(tmp.Timestamp)); age > maxPayloadAge {
+			fmt.Printf("[DEBUG] RequestSystemData: Rejecting data collected %v ago (max %v)\n", age.Round(time.Second), maxPayloadAge)
+			err = ErrStalePayload
+		}
+	}
 	// Overwrite the destination only once after successful decode/convert
 	if err == nil {
 		*data = tmp
@@ -481,6 +536,10 @@ func (ws *WsConn) convertMapToContainerStats(containerMap map[interface{}]interf
 func (ws *WsConn) GetFingerprint(token string, signer ssh.Signer, needSysInfo bool) (common.FingerprintResponse, error) {
 	fmt.Printf("[DEBUG] GetFingerprint: Starting authentication with signature verification\n")
 	var clientFingerprint common.FingerprintResponse
+	var handshakeDeadline time.Time
+	if handshakeConfig.Timeout > 0 {
+		handshakeDeadline = time.Now().Add(handshakeConfig.Timeout)
+	}
 	challenge := []byte(token)
 
 	signature, err := signer.Sign(nil, challenge)
@@ -508,18 +567,26 @@ func (ws *WsConn) GetFingerprint(token string, signer ssh.Signer, needSysInfo bo
 	select {
 	case message = <-ws.responseChan:
 		fmt.Printf("[DEBUG] GetFingerprint: Received response from agent\n")
-	case <-time.After(5 * time.Second):
+	case <-time.After(handshakeWait(handshakeConfig.SignedTimeout, handshakeDeadline)):
+		if handshakeConfig.DisableUnsignedFallback {
+			fmt.Printf("[DEBUG] GetFingerprint: Timeout waiting for response, unsigned fallback disabled\n")
+			return clientFingerprint, errors.New("request expired")
+		}
 		fmt.Printf("[DEBUG] GetFingerprint: Timeout waiting for response, trying without signature verification\n")
 		// If no response, try without signature verification (for SNMP monitor agents)
-		return ws.GetFingerprintWithoutSignature(token, needSysInfo)
+		return ws.getFingerprintWithoutSignature(token, needSysInfo, handshakeDeadline)
 	}
 	defer message.Close()
 
 	err = cbor.Unmarshal(message.Data.Bytes(), &clientFingerprint)
 	if err != nil {
+		if handshakeConfig.DisableUnsignedFallback {
+			fmt.Printf("[DEBUG] GetFingerprint: Failed to unmarshal response: %v, unsigned fallback disabled\n", err)
+			return clientFingerprint, err
+		}
 		fmt.Printf("[DEBUG] GetFingerprint: Failed to unmarshal response: %v, trying without signature verification\n", err)
 		// If signature verification failed, try without signature (for SNMP monitor agents)
-		return ws.GetFingerprintWithoutSignature(token, needSysInfo)
+		return ws.getFingerprintWithoutSignature(token, needSysInfo, handshakeDeadline)
 	}
 
 	fmt.Printf("[DEBUG] GetFingerprint: Successfully authenticated with signature verification, fingerprint: %s\n", clientFingerprint.Fingerprint)
@@ -528,6 +595,11 @@ func (ws *WsConn) GetFingerprint(token string, signer ssh.Signer, needSysInfo bo
 
 // GetFingerprintWithoutSignature authenticates with SNMP monitor agents that skip signature verification.
 func (ws *WsConn) GetFingerprintWithoutSignature(token string, needSysInfo bool) (common.FingerprintResponse, error) {
+	return ws.getFingerprintWithoutSignature(token, needSysInfo, time.Time{})
+}
+
+// getFingerprintWithoutSignature performs the unsigned handshake, giving up at handshakeDeadline if it is set.
+func (ws *WsConn) getFingerprintWithoutSignature(token string, needSysInfo bool, handshakeDeadline time.Time) (common.FingerprintResponse, error) {
 	fmt.Printf("[DEBUG] GetFingerprintWithoutSignature: Starting authentication without signature verification\n")
 	var clientFingerprint common.FingerprintResponse
 
@@ -550,7 +622,7 @@ func (ws *WsConn) GetFingerprintWithoutSignature(token string, needSysInfo bool)
 	select {
 	case message = <-ws.responseChan:
 		fmt.Printf("[DEBUG] GetFingerprintWithoutSignature: Received response from agent\n")
-	case <-time.After(10 * time.Second):
+	case <-time.After(handshakeWait(handshakeConfig.UnsignedTimeout, handshakeDeadline)):
 		fmt.Printf("[DEBUG] GetFingerprintWithoutSignature: Timeout waiting for response\n")
 		return clientFingerprint, errors.New("request expired")
 	}
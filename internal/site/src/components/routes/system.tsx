@@ -3,7 +3,7 @@ import { Plural, Trans, useLingui } from "@lingui/react/macro"
 import { useStore } from "@nanostores/react"
 import { getPagePath } from "@nanostores/router"
 import { timeTicks } from "d3-time"
-import { ClockArrowUp, CpuIcon, GlobeIcon, LayoutGridIcon, MonitorIcon, XIcon } from "lucide-react"
+import { ClockArrowUp, CpuIcon, GlobeIcon, LayoutGridIcon, MonitorIcon, NetworkIcon, ServerIcon, XIcon } from "lucide-react"
 import { subscribeKeys } from "nanostores"
 import React, { type JSX, memo, useCallback, useEffect, useMemo, useRef, useState } from "react"
 import AreaChartDefault from "@/components/charts/area-chart"
@@ -311,6 +311,9 @@ export default memo(function SystemDetail({ name }: { name: string }) {
 		} else {
 			uptime = <Plural value={Math.trunc(system.info?.u / 86400)} one="# day" other="# days" />
 		}
+		// SNMP monitors report extra details: which collector a device's data came from, and
+		// for the monitor's own entry how many of its devices are up
+		const extraInfo = system.info.ei ?? {}
 		return [
 			{ value: getHostDisplayValue(system), Icon: GlobeIcon },
 			{
@@ -327,6 +330,13 @@ export default memo(function SystemDetail({ name }: { name: string }) {
 				Icon: CpuIcon,
 				hide: !system.info.m,
 			},
+			{
+				value: `${extraInfo.devices_up}/${extraInfo.devices_total}`,
+				Icon: ServerIcon,
+				label: t`Devices up`,
+				hide: extraInfo.devices_total === undefined,
+			},
+			{ value: extraInfo.collector_id, Icon: NetworkIcon, label: t`Collector` },
 		] as {
 			value: string | number | undefined
 			label?: string
@@ -19,6 +19,7 @@ import { Button } from "../ui/button"
 import {
 	cn,
 	copyToClipboard,
+	dashboardValue,
 	decimalString,
 	formatBytes,
 	formatTemperature,
@@ -53,7 +54,7 @@ import {
 } from "../ui/alert-dialog"
 import { buttonVariants } from "../ui/button"
 import { t } from "@lingui/core/macro"
-import { MeterState, SystemStatus } from "@/lib/enums"
+import { DashboardSummary, MeterState, SystemStatus } from "@/lib/enums"
 import { $router, Link } from "../router"
 import { getPagePath } from "@nanostores/router"
 import { isReadOnlyUser, pb } from "@/lib/api"
@@ -240,7 +241,7 @@ export default function SystemsTableColumns(viewMode: "table" | "grid"): ColumnD
 			},
 		},
 		{
-			accessorFn: ({ info }) => info.dt,
+			accessorFn: ({ info }) => dashboardValue(info, "dt", DashboardSummary.Temp),
 			id: "temp",
 			name: () => t({ message: "Temp", comment: "Temperature label in systems table" }),
 			size: 50,
@@ -47,6 +47,25 @@ export enum BatteryState {
 	Idle,
 }
 
+/** Bits of SystemInfo.dset, one per dashboard summary. Summaries of zero are left out of the
+ * system info, so a flagged summary that is missing is a reading of zero. */
+export enum DashboardSummary {
+	Temp = 1 << 0,
+	Humidity = 1 << 1,
+	CO2 = 1 << 2,
+	Pressure = 1 << 3,
+	PM25 = 1 << 4,
+	PM10 = 1 << 5,
+	VOC = 1 << 6,
+	Voltage = 1 << 7,
+	Current = 1 << 8,
+	Power = 1 << 9,
+	Fan = 1 << 10,
+	Battery = 1 << 11,
+	Runtime = 1 << 12,
+	UPSLoad = 1 << 13,
+}
+
 /** Time format */
 export enum HourFormat {
 	// Default = "Default",
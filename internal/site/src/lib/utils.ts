@@ -5,8 +5,8 @@ import { useEffect, useState } from "react"
 import { twMerge } from "tailwind-merge"
 import { prependBasePath } from "@/components/router"
 import { toast } from "@/components/ui/use-toast"
-import type { ChartTimeData, FingerprintRecord, SemVer, SystemRecord } from "@/types"
-import { HourFormat, MeterState, Unit } from "./enums"
+import type { ChartTimeData, FingerprintRecord, SemVer, SystemInfo, SystemRecord } from "@/types"
+import { DashboardSummary, HourFormat, MeterState, Unit } from "./enums"
 import { $copyContent, $userSettings } from "./stores"
 import { listenKeys } from "nanostores"
 
@@ -174,6 +174,20 @@ export function useBrowserStorage<T>(key: string, defaultValue: T, storageInterf
 	return [value, setValue]
 }
 
+/** Get a dashboard summary from system info, or undefined if the agent didn't report it.
+ * A summary of zero is omitted from the info but flagged in dset. */
+export function dashboardValue(
+	info: SystemInfo | undefined,
+	key: "dt" | "dh" | "dco2" | "dpr" | "dpm25" | "dpm10" | "dvoc" | "dvolt" | "dcur" | "dpw" | "dfan" | "dbatc" | "drt" | "dupsl",
+	summary: DashboardSummary
+): number | undefined {
+	const value = info?.[key]
+	if (value !== undefined) {
+		return value
+	}
+	return (info?.dset ?? 0) & summary ? 0 : undefined
+}
+
 /** Format temperature to user's preferred unit */
 export function formatTemperature(celsius: number, unit?: Unit): { value: number; unit: string } {
 	if (!unit) {
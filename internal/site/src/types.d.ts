@@ -89,6 +89,24 @@ export interface SystemInfo {
 	dpm10?: number
 	/** dashboard display VOC (ppb) */
 	dvoc?: number
+	/** dashboard display voltage (V) */
+	dvolt?: number
+	/** dashboard display current (A) */
+	dcur?: number
+	/** dashboard display power (W) */
+	dpw?: number
+	/** dashboard display fan speed (RPM) */
+	dfan?: number
+	/** dashboard display lowest UPS battery charge (%) */
+	dbatc?: number
+	/** dashboard display lowest UPS runtime (minutes) */
+	drt?: number
+	/** dashboard display highest UPS load (%) */
+	dupsl?: number
+	/** extra details from SNMP monitors, e.g. collector_id and, for the monitor itself, devices_total / devices_up */
+	ei?: Record<string, string>
+	/** bit set of the dashboard summaries the agent reported (see DashboardSummary) */
+	dset?: number
 }
 
 export interface SystemStats {
@@ -161,6 +179,20 @@ export interface SystemStats {
 	pm10?: Record<string, number>
 	/** VOC (ppb) */
 	voc?: Record<string, number>
+	/** voltage (V) */
+	volt?: Record<string, number>
+	/** current (A) */
+	cur?: Record<string, number>
+	/** power (W) */
+	pw?: Record<string, number>
+	/** fan speed (RPM) */
+	fan?: Record<string, number>
+	/** UPS battery charge (%) */
+	batc?: Record<string, number>
+	/** UPS runtime (minutes) */
+	rt?: Record<string, number>
+	/** UPS load (%) */
+	upsl?: Record<string, number>
 	/** extra filesystems */
 	efs?: Record<string, ExtraFsStats>
 	/** GPU data */
@@ -2,8 +2,13 @@ package snmpmonitor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Agent represents the SNMP monitor
@@ -12,10 +17,26 @@ type Agent struct {
 	hubConfig *HubConfig
 	webServer *WebServer
 	pollers   map[string]*Poller
-	hubClient *HubClient
+	pollersMu sync.RWMutex
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+	startedAt time.Time
+
+	// hubClient is replaced on hub configuration changes and read without holding updateMu
+	hubClient atomic.Pointer[HubClient]
+
+	// configPath is the file the configuration was loaded from and is saved to
+	configPath string
+	// limiter caps concurrent polls across all devices, guarded by pollersMu
+	limiter *pollLimiter
+	// updateMu serializes configuration changes from the web UI and reloads
+	updateMu sync.Mutex
+	// capabilities holds the MIBs each device was last found to support
+	capabilities *capabilityCache
+	// influx writes metrics to InfluxDB and mqtt publishes them to a broker; nil when not configured
+	influx *InfluxSink
+	mqtt   *MQTTSink
 }
 
 // NewAgent creates a new SNMP monitor
@@ -28,11 +49,18 @@ func NewAgent(configPath string) (*Agent, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	agent := &Agent{
-		config:    config,
-		hubConfig: hubConfig,
-		pollers:   make(map[string]*Poller),
-		ctx:       ctx,
-		cancel:    cancel,
+		config:     config,
+		hubConfig:  hubConfig,
+		pollers:    make(map[string]*Poller),
+		ctx:        ctx,
+		cancel:     cancel,
+		startedAt:  time.Now(),
+		configPath: configPath,
+		limiter:    newPollLimiter(config.GetDefaults().MaxConcurrentPolls),
+
+		capabilities: newCapabilityCache(),
+		influx:       newInfluxSink(config.Influx),
+		mqtt:         newMQTTSink(config.MQTT),
 	}
 
 	// Initialize web server
@@ -43,36 +71,62 @@ func NewAgent(configPath string) (*Agent, error) {
 	}
 
 	// Initialize hub client
-	agent.hubClient, err = NewHubClient(*hubConfig)
+	hubClient, err := NewHubClient(*hubConfig)
 	if err != nil {
 		cancel()
 		return nil, err
 	}
+	agent.hubClient.Store(hubClient)
+	if hubConfig.VerifyAtStartup && hubConfig.URL != "" {
+		if err := hubClient.verifyReachable(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
 
 	return agent, nil
 }
 
 // Run starts the SNMP monitor
 func (a *Agent) Run() error {
-	// Start web server
-	a.wg.Add(1)
-	go func() {
-		defer a.wg.Done()
+	// Bind the web server before starting the pollers, so a required web server that
+	// can't start stops the monitor before it polls anything
+	if a.webServer.config.Enabled() {
 		log.Printf("Starting web server on port %d", a.webServer.config.Port)
-		if err := a.webServer.Start(); err != nil {
-			log.Printf("Web server error: %v", err)
+		listener, err := a.webServer.Listen()
+		if err != nil {
+			if a.webServer.config.Required {
+				return fmt.Errorf("web server failed to start: %w", err)
+			}
+			log.Printf("Web server failed to start, continuing without it: %v", err)
+		} else {
+			a.wg.Add(1)
+			go func() {
+				defer a.wg.Done()
+				if err := a.webServer.Serve(listener); err != nil {
+					log.Printf("Web server error: %v", err)
+				}
+			}()
 		}
-	}()
+	} else {
+		log.Println("Web server disabled (port 0), running headless")
+	}
 
 	// Start pollers for each device
 	for _, device := range a.config.Devices {
-		poller, err := NewPoller(device, a.hubClient)
+		if device.Disabled {
+			log.Printf("Device %s is disabled, not polling", device.Name)
+			continue
+		}
+		poller, err := a.newPoller(device)
 		if err != nil {
 			log.Printf("Failed to create poller for device %s: %v", device.Name, err)
 			continue
 		}
 
+		a.pollersMu.Lock()
 		a.pollers[device.Name] = poller
+		a.pollersMu.Unlock()
 		a.wg.Add(1)
 		go func(p *Poller) {
 			defer a.wg.Done()
@@ -81,15 +135,102 @@ func (a *Agent) Run() error {
 		}(poller)
 	}
 
+	a.startSelfReport()
+
 	// Wait for context cancellation
 	<-a.ctx.Done()
 	log.Println("Shutting down container agent...")
 
-	// Wait for all goroutines to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.webServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Web server shutdown error: %v", err)
+	}
+
+	// Wait for all goroutines to finish, then write what the pollers flushed
 	a.wg.Wait()
+	if a.influx != nil {
+		a.influx.Close()
+	}
+	if a.mqtt != nil {
+		a.mqtt.Close()
+	}
 	return nil
 }
 
+// newPoller creates a poller for a device with the configured defaults applied
+func (a *Agent) newPoller(device DeviceConfig) (*Poller, error) {
+	poller, err := NewPoller(device, a.hubClient.Load())
+	if err != nil {
+		return nil, err
+	}
+	poller.communities = device.mergeCommunities(a.config.GetDefaults().Communities)
+	poller.SetSinks(a.sinks())
+	poller.includeOID = a.config.GetDefaults().IncludeOIDInPayload
+	poller.limiter = a.limiter
+	poller.historySize = a.config.GetDefaults().HistorySize
+	poller.historyGaps = a.config.GetDefaults().HistoryGaps
+	poller.roundDigits = a.config.GetDefaults().RoundDigits
+	poller.capabilities = a.capabilities
+	poller.capabilityRefresh = a.config.GetDefaults().GetCapabilityRefresh()
+	return poller, nil
+}
+
+// startSelfReport registers the monitor as a collector device on the hub when enabled
+func (a *Agent) startSelfReport() {
+	if a.hubConfig.ReportSelf {
+		a.hubClient.Load().ReportSelf(collectorName(), a.collectorData)
+	}
+}
+
+// sinks returns the configured outputs that receive metrics besides the hub
+func (a *Agent) sinks() []metricSink {
+	var sinks []metricSink
+	if a.influx != nil {
+		sinks = append(sinks, a.influx)
+	}
+	if a.mqtt != nil {
+		sinks = append(sinks, a.mqtt)
+	}
+	return sinks
+}
+
+// reloadSinks replaces the sinks whose settings changed and points all running pollers
+// at the new ones. Replaced sinks send what they have buffered before they stop.
+func (a *Agent) reloadSinks(config *Config, influxChanged, mqttChanged bool) {
+	oldInflux, oldMQTT := a.influx, a.mqtt
+	if influxChanged {
+		a.influx = newInfluxSink(config.Influx)
+	}
+	if mqttChanged {
+		a.mqtt = newMQTTSink(config.MQTT)
+	}
+
+	a.pollersMu.RLock()
+	sinks := a.sinks()
+	for _, poller := range a.pollers {
+		poller.SetSinks(sinks)
+	}
+	a.pollersMu.RUnlock()
+
+	if influxChanged && oldInflux != nil {
+		oldInflux.Close()
+	}
+	if mqttChanged && oldMQTT != nil {
+		oldMQTT.Close()
+	}
+	log.Println("Output sinks reloaded with new configuration")
+}
+
+// reloadHubClient points all running pollers at the current hub client
+func (a *Agent) reloadHubClient() {
+	a.pollersMu.RLock()
+	defer a.pollersMu.RUnlock()
+	for _, poller := range a.pollers {
+		poller.SetHubClient(a.hubClient.Load())
+	}
+}
+
 // Stop stops the container agent
 func (a *Agent) Stop() {
 	a.cancel()
@@ -102,12 +243,92 @@ func (a *Agent) GetConfig() *Config {
 
 // GetPollerStatus returns the status and metrics for a specific device
 func (a *Agent) GetPollerStatus(deviceName string) (string, map[string]float64) {
-	if poller, exists := a.pollers[deviceName]; exists {
+	a.pollersMu.RLock()
+	poller, exists := a.pollers[deviceName]
+	a.pollersMu.RUnlock()
+	if exists {
 		return poller.GetStatus(), poller.GetLastValues()
 	}
 	return "Not Found", make(map[string]float64)
 }
 
+// ErrDeviceNotFound is returned when a device name is not in the configuration
+var ErrDeviceNotFound = errors.New("device not found")
+
+// RemoveDevice deletes a device from the configuration, saves it and stops the device's poller.
+// Other pollers keep running.
+func (a *Agent) RemoveDevice(name string) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	index := a.config.FindDevice(name)
+	if index < 0 {
+		return ErrDeviceNotFound
+	}
+
+	ip := a.config.Devices[index].IP
+	newConfig := a.config.Clone()
+	newConfig.Devices = append(newConfig.Devices[:index], newConfig.Devices[index+1:]...)
+	if err := newConfig.SaveConfig(a.configPath); err != nil {
+		return err
+	}
+	a.config = newConfig
+
+	a.pollersMu.Lock()
+	poller, exists := a.pollers[name]
+	delete(a.pollers, name)
+	a.pollersMu.Unlock()
+	if exists {
+		poller.Stop()
+	}
+	a.releaseHubConnection(ip)
+	a.capabilities.delete(name)
+
+	log.Printf("Removed device %s", name)
+	return nil
+}
+
+// releaseHubConnection closes the hub connection of an IP once no enabled device uses it.
+// Hub connections are kept per IP, so devices on other ports of the same IP share one.
+func (a *Agent) releaseHubConnection(ip string) {
+	for _, device := range a.config.Devices {
+		if device.IP == ip && !device.Disabled {
+			return
+		}
+	}
+	a.hubClient.Load().RemoveDevice(ip)
+}
+
+// GetPoller returns the running poller for a device
+func (a *Agent) GetPoller(deviceName string) (*Poller, bool) {
+	a.pollersMu.RLock()
+	defer a.pollersMu.RUnlock()
+	poller, exists := a.pollers[deviceName]
+	return poller, exists
+}
+
+// Ready reports whether the monitor is doing useful work: a poller has produced a value
+// or a device is connected to the hub
+func (a *Agent) Ready() bool {
+	a.pollersMu.RLock()
+	for _, poller := range a.pollers {
+		if len(poller.GetLastValues()) > 0 {
+			a.pollersMu.RUnlock()
+			return true
+		}
+	}
+	a.pollersMu.RUnlock()
+
+	return a.hubClient.Load().Connected()
+}
+
+// GetPollStats returns the load on the poll concurrency limit
+func (a *Agent) GetPollStats() PollStats {
+	a.pollersMu.RLock()
+	defer a.pollersMu.RUnlock()
+	return a.limiter.stats()
+}
+
 // GetHubConfig returns the hub configuration
 func (a *Agent) GetHubConfig() *HubConfig {
 	return a.hubConfig
@@ -118,9 +339,57 @@ func (a *Agent) GetWebServerConfig() *WebServerConfig {
 	return a.webServer.config
 }
 
-// UpdateConfig updates the configuration and restarts pollers and hub client
+// UpdateConfig saves the configuration to the config file and applies it
 func (a *Agent) UpdateConfig(newConfig *Config) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	if err := newConfig.Influx.validate(); err != nil {
+		return err
+	}
+	if err := newConfig.MQTT.validate(); err != nil {
+		return err
+	}
+	if err := newConfig.dedupeOIDs(); err != nil {
+		return err
+	}
+	if err := newConfig.validateMetrics(); err != nil {
+		return err
+	}
+	if err := newConfig.checkHubConflicts(); err != nil {
+		return err
+	}
+	if err := newConfig.SaveConfig(a.configPath); err != nil {
+		return err
+	}
+	return a.applyConfig(newConfig)
+}
+
+// Reload re-reads the config file and applies it. If the file is invalid the
+// current configuration stays in effect and the error is returned.
+func (a *Agent) Reload() error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	newConfig, _, _, err := LoadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Reloading configuration from %s", a.configPath)
+	return a.applyConfig(newConfig)
+}
+
+// applyConfig makes a validated configuration current and restarts the hub client and
+// the pollers of devices whose configuration changed. When only the hub settings changed,
+// the pollers keep running and are re-pointed at the new hub client. Pollers of unchanged
+// devices are never interrupted.
+func (a *Agent) applyConfig(newConfig *Config) error {
+	devicesChanged := !reflect.DeepEqual(a.config.Devices, newConfig.Devices)
+	defaultsChanged := !reflect.DeepEqual(a.config.GetDefaults(), newConfig.GetDefaults())
+	influxChanged := !reflect.DeepEqual(a.config.Influx, newConfig.Influx)
+	mqttChanged := !reflect.DeepEqual(a.config.MQTT, newConfig.MQTT)
 	a.config = newConfig
+	newConfig.warnExtremeScales()
 
 	// Check if hub config changed
 	hubConfigChanged := false
@@ -129,45 +398,112 @@ func (a *Agent) UpdateConfig(newConfig *Config) error {
 		oldURL := a.hubConfig.URL
 		oldToken := a.hubConfig.Token
 		oldKey := a.hubConfig.Key
+		oldReportSelf := a.hubConfig.ReportSelf
+		oldConnectTimeout := a.hubConfig.ConnectTimeout
+		oldSkipSignature := a.hubConfig.SkipSignatureVerification
+		oldMaxSendFailures := a.hubConfig.MaxSendFailures
+		oldCollectorID := a.hubConfig.CollectorID
 
 		a.hubConfig.URL = newConfig.Hub.URL
 		a.hubConfig.Token = newConfig.Hub.Token
 		a.hubConfig.Key = newConfig.Hub.Key
+		a.hubConfig.ReportSelf = newConfig.Hub.ReportSelf
+		a.hubConfig.ConnectTimeout = newConfig.Hub.ConnectTimeout
+		a.hubConfig.SkipSignatureVerification = newConfig.Hub.SkipSignatureVerification
+		a.hubConfig.VerifyAtStartup = newConfig.Hub.VerifyAtStartup
+		a.hubConfig.MaxSendFailures = newConfig.Hub.MaxSendFailures
+		a.hubConfig.CollectorID = newConfig.Hub.CollectorID
 
 		// Check if any hub setting changed
-		if oldURL != a.hubConfig.URL || oldToken != a.hubConfig.Token || oldKey != a.hubConfig.Key {
+		if oldURL != a.hubConfig.URL || oldToken != a.hubConfig.Token || oldKey != a.hubConfig.Key ||
+			oldReportSelf != a.hubConfig.ReportSelf || oldConnectTimeout != a.hubConfig.ConnectTimeout ||
+			oldSkipSignature != a.hubConfig.SkipSignatureVerification || oldMaxSendFailures != a.hubConfig.MaxSendFailures ||
+			oldCollectorID != a.hubConfig.CollectorID {
 			hubConfigChanged = true
 			log.Println("Hub configuration changed, will restart hub client")
 		}
 	}
 
-	// Restart hub client if config changed
+	// Restart hub client if config changed. Running pollers are re-pointed at the new
+	// client before the old one is closed so notifications never go to a dead client.
 	if hubConfigChanged {
-		var err error
-		a.hubClient, err = NewHubClient(*a.hubConfig)
+		hubClient, err := NewHubClient(*a.hubConfig)
 		if err != nil {
 			log.Printf("Failed to create new hub client: %v", err)
 			return err
 		}
+		oldHubClient := a.hubClient.Swap(hubClient)
+		a.reloadHubClient()
+		oldHubClient.Close()
 		log.Println("Hub client restarted with new configuration")
+		a.startSelfReport()
+	}
+
+	if influxChanged || mqttChanged {
+		a.reloadSinks(newConfig, influxChanged, mqttChanged)
 	}
 
-	// Stop existing pollers
+	if !devicesChanged && !defaultsChanged {
+		log.Println("Device configuration unchanged, pollers kept running")
+		return nil
+	}
+
+	a.pollersMu.Lock()
+	defer a.pollersMu.Unlock()
+
+	if defaultsChanged {
+		a.limiter = newPollLimiter(newConfig.GetDefaults().MaxConcurrentPolls)
+	}
+
+	newDevices := make(map[string]DeviceConfig, len(newConfig.Devices))
+	for _, device := range newConfig.Devices {
+		newDevices[device.Name] = device
+	}
+
+	// Stop the pollers of removed, disabled or changed devices, or all pollers when the
+	// defaults changed, letting in-flight polls finish and flush in parallel. Devices that
+	// no longer report from their IP have its hub connection closed once no other device uses it.
+	var stopped sync.WaitGroup
+	var gone []string
 	for name, poller := range a.pollers {
-		poller.Stop()
+		device, ok := newDevices[name]
+		if ok && !defaultsChanged && !device.Disabled && reflect.DeepEqual(device, poller.device) {
+			continue
+		}
+		if !ok || device.Disabled || device.IP != poller.device.IP {
+			gone = append(gone, poller.device.IP)
+		}
+		stopped.Add(1)
+		go func(p *Poller) {
+			defer stopped.Done()
+			p.Stop()
+		}(poller)
 		delete(a.pollers, name)
 	}
+	stopped.Wait()
+	for _, ip := range gone {
+		a.releaseHubConnection(ip)
+	}
 
-	// Start new pollers
+	// Start pollers for new and changed devices
 	for _, device := range newConfig.Devices {
-		poller, err := NewPoller(device, a.hubClient)
+		if device.Disabled {
+			log.Printf("Device %s is disabled, not polling", device.Name)
+			continue
+		}
+		if _, running := a.pollers[device.Name]; running {
+			continue
+		}
+		poller, err := a.newPoller(device)
 		if err != nil {
 			log.Printf("Failed to create poller for device %s: %v", device.Name, err)
 			continue
 		}
 
 		a.pollers[device.Name] = poller
+		a.wg.Add(1)
 		go func(p *Poller) {
+			defer a.wg.Done()
 			log.Printf("Starting poller for device %s", p.device.Name)
 			p.Start(a.ctx)
 		}(poller)
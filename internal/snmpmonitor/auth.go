@@ -0,0 +1,75 @@
+package snmpmonitor
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WebAuthConfig protects the web server with HTTP basic auth, a bearer token, or both
+type WebAuthConfig struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Token is accepted as "Authorization: Bearer <token>", e.g. for scripts and Prometheus
+	Token string `json:"token,omitempty"`
+}
+
+// validate checks that the auth block configures a complete set of credentials
+func (a *WebAuthConfig) validate() error {
+	if (a.Username == "") != (a.Password == "") {
+		return fmt.Errorf("web_server auth requires both username and password")
+	}
+	if a.Username == "" && a.Token == "" {
+		return fmt.Errorf("web_server auth requires a username and password or a token")
+	}
+	return nil
+}
+
+// authorized reports whether the request carries the configured bearer token or basic auth credentials
+func (a *WebAuthConfig) authorized(r *http.Request) bool {
+	if a.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureCompare(token, a.Token) {
+			return true
+		}
+	}
+	if a.Username != "" {
+		username, password, ok := r.BasicAuth()
+		// Check both so the response time doesn't reveal which one was wrong
+		usernameOK := secureCompare(username, a.Username)
+		passwordOK := secureCompare(password, a.Password)
+		if ok && usernameOK && passwordOK {
+			return true
+		}
+	}
+	return false
+}
+
+// secureCompare compares two credentials in constant time. Both are hashed first so
+// the comparison doesn't leak the length of the configured credential.
+func secureCompare(given, expected string) bool {
+	givenHash := sha256.Sum256([]byte(given))
+	expectedHash := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(givenHash[:], expectedHash[:]) == 1
+}
+
+// requireAuth rejects requests without valid credentials with 401
+func (ws *WebServer) requireAuth(next http.Handler) http.Handler {
+	auth := ws.config.Auth
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Orchestrator probes usually can't send credentials, and reveal nothing but "ok"
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !auth.authorized(r) {
+			if auth.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Beszel SNMP Monitor", charset="UTF-8"`)
+			}
+			ws.sendJSONError(w, "Authentication required", fmt.Errorf("missing or invalid credentials"), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
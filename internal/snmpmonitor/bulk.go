@@ -0,0 +1,174 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// useGetBulk reports whether a poll of count OIDs should use GETBULK
+func (d *DeviceConfig) useGetBulk(count int) bool {
+	return d.UseGetBulk && count > d.GetBulkThreshold
+}
+
+// GetMaxOIDsPerRequest returns the maximum number of OIDs sent in one GETBULK request
+func (d *DeviceConfig) GetMaxOIDsPerRequest() int {
+	if d.MaxOIDsPerRequest <= 0 {
+		return gosnmp.MaxOids
+	}
+	return d.MaxOIDsPerRequest
+}
+
+// GetMaxRepetitions returns the GETBULK max-repetitions value
+func (d *DeviceConfig) GetMaxRepetitions() uint32 {
+	if d.MaxRepetitions <= 0 {
+		return 1
+	}
+	return uint32(d.MaxRepetitions)
+}
+
+// validateGetBulk checks the device's GETBULK settings
+func (d *DeviceConfig) validateGetBulk() error {
+	if d.NonRepeaters < 0 || d.NonRepeaters > 255 {
+		return fmt.Errorf("non_repeaters must be between 0 and 255")
+	}
+	if d.MaxRepetitions < 0 {
+		return fmt.Errorf("max_repetitions must not be negative")
+	}
+	if d.MaxOIDsPerRequest > gosnmp.MaxOids {
+		return fmt.Errorf("max_oids_per_request must be at most %d", gosnmp.MaxOids)
+	}
+	return nil
+}
+
+// getBulk fetches the OIDs with GETBULK requests of at most max_oids_per_request OIDs.
+// GETBULK returns the OIDs that follow each requested OID, so each request starts just
+// before the wanted OID and only exact matches are kept. OIDs the device did not return,
+// and batches it rejects as too big, are fetched with a plain GET.
+func (p *Poller) getBulk(params *gosnmp.GoSNMP, oids []string) ([]gosnmp.SnmpPDU, error) {
+	batchSize := p.device.GetMaxOIDsPerRequest()
+
+	var variables []gosnmp.SnmpPDU
+	var missing []string
+	for start := 0; start < len(oids); start += batchSize {
+		batch := oids[start:min(start+batchSize, len(oids))]
+
+		requested, nonRepeaters, maxRepetitions := p.bulkRequest(batch)
+
+		result, err := params.GetBulk(requested, uint8(nonRepeaters), maxRepetitions)
+		if err == nil && result.Error == gosnmp.TooBig {
+			log.Printf("GETBULK response too big for device %s, falling back to GET", p.device.Name)
+			missing = append(missing, batch...)
+			continue
+		}
+		if err == nil && result.Error != gosnmp.NoError {
+			err = &pduError{status: result.Error}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		wanted := make(map[string]bool, len(batch))
+		for _, oid := range batch {
+			wanted[oid] = true
+		}
+		for _, variable := range result.Variables {
+			if wanted[variable.Name] {
+				variables = append(variables, variable)
+				delete(wanted, variable.Name)
+			}
+		}
+		for _, oid := range batch {
+			if wanted[oid] {
+				missing = append(missing, oid)
+			}
+		}
+	}
+
+	for start := 0; start < len(missing); start += batchSize {
+		batch := missing[start:min(start+batchSize, len(missing))]
+		fetched, err := p.get(params, batch, 0)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, fetched...)
+	}
+	return variables, nil
+}
+
+// bulkRequest builds the OIDs, non-repeaters and max-repetitions of one GETBULK for the batch.
+// Without repeating metrics every OID is requested once with the device's GETBULK settings.
+// Otherwise scalar OIDs are sent first as non-repeaters, and repeating metrics are grouped
+// by table column so each column is requested once, starting just before its first
+// configured row, with enough repetitions to reach all of its configured rows.
+func (p *Poller) bulkRequest(batch []string) ([]string, int, uint32) {
+	repeating := make(map[string]bool)
+	for _, metric := range p.device.Metrics {
+		if metric.Repeating {
+			repeating[metric.OID] = true
+		}
+	}
+
+	if len(repeating) == 0 {
+		requested := make([]string, len(batch))
+		for i, oid := range batch {
+			requested[i] = precedingOID(oid)
+		}
+		return requested, min(p.device.NonRepeaters, len(batch)), p.device.GetMaxRepetitions()
+	}
+
+	var scalars []string
+	columns := make(map[string][]string)
+	var columnOrder []string
+	for _, oid := range batch {
+		if !repeating[oid] {
+			scalars = append(scalars, precedingOID(oid))
+			continue
+		}
+		column := oid[:max(strings.LastIndexByte(oid, '.'), 0)]
+		if _, ok := columns[column]; !ok {
+			columnOrder = append(columnOrder, column)
+		}
+		columns[column] = append(columns[column], oid)
+	}
+
+	requested := scalars
+	maxRepetitions := p.device.GetMaxRepetitions()
+	for _, column := range columnOrder {
+		rows := columns[column]
+		sort.Slice(rows, func(i, j int) bool { return lastArc(rows[i]) < lastArc(rows[j]) })
+		requested = append(requested, precedingOID(rows[0]))
+		if span := uint32(lastArc(rows[len(rows)-1]) - lastArc(rows[0]) + 1); span > maxRepetitions {
+			maxRepetitions = span
+		}
+	}
+	return requested, len(scalars), maxRepetitions
+}
+
+// lastArc returns the last sub-identifier of an OID, or 0 if it isn't numeric
+func lastArc(oid string) uint64 {
+	n, _ := strconv.ParseUint(oid[strings.LastIndexByte(oid, '.')+1:], 10, 32)
+	return n
+}
+
+// precedingOID returns an OID that sorts immediately before oid in the common case,
+// so that a GETNEXT or GETBULK starting from it returns oid. The last arc is
+// decremented, or dropped when it is already zero.
+func precedingOID(oid string) string {
+	i := strings.LastIndexByte(oid, '.')
+	if i <= 0 {
+		return oid
+	}
+	last, err := strconv.ParseUint(oid[i+1:], 10, 32)
+	if err != nil {
+		return oid
+	}
+	if last == 0 {
+		return oid[:i]
+	}
+	return oid[:i+1] + strconv.FormatUint(last-1, 10)
+}
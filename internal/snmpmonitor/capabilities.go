@@ -0,0 +1,153 @@
+package snmpmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// defaultCapabilityRefresh is how often running pollers probe their device's capabilities
+const defaultCapabilityRefresh = time.Hour
+
+// capabilityProbe is a standard MIB subtree whose presence is checked on a device
+type capabilityProbe struct {
+	name string
+	oid  string
+}
+
+// capabilityProbes lists the MIBs reported in a device's capabilities
+var capabilityProbes = []capabilityProbe{
+	{name: "system", oid: ".1.3.6.1.2.1.1"},
+	// IF-MIB ifTable and ifXTable, which has the 64-bit counters
+	{name: "interfaces", oid: ".1.3.6.1.2.1.2.2"},
+	{name: "interfaces_hc", oid: ".1.3.6.1.2.1.31.1.1"},
+	{name: "host_resources", oid: ".1.3.6.1.2.1.25"},
+	{name: "entity", oid: ".1.3.6.1.2.1.47.1.1.1"},
+	{name: "entity_sensors", oid: entPhySensorTable},
+	{name: "ups", oid: ".1.3.6.1.2.1.33.1"},
+	{name: "lm_sensors", oid: ".1.3.6.1.4.1.2021.13.16"},
+	{name: "cisco_envmon", oid: ".1.3.6.1.4.1.9.9.13.1"},
+	{name: "apc_powernet", oid: ".1.3.6.1.4.1.318.1.1.1"},
+}
+
+// DeviceCapabilities records which standard MIBs a device answered for
+type DeviceCapabilities struct {
+	// MIBs maps each probed MIB name to whether the device has any object under it
+	MIBs      map[string]bool `json:"mibs"`
+	CheckedAt time.Time       `json:"checked_at"`
+}
+
+// probeCapabilities sends a GETNEXT for each probed MIB. A MIB is supported when the next
+// object the device returns is inside it.
+func probeCapabilities(params *gosnmp.GoSNMP) (DeviceCapabilities, error) {
+	caps := DeviceCapabilities{MIBs: make(map[string]bool, len(capabilityProbes))}
+	for _, probe := range capabilityProbes {
+		result, err := params.GetNext([]string{probe.oid})
+		if err != nil {
+			return DeviceCapabilities{}, fmt.Errorf("probe %s: %w", probe.name, err)
+		}
+		supported := false
+		for _, variable := range result.Variables {
+			if variable.Type != gosnmp.EndOfMibView && strings.HasPrefix(variable.Name, probe.oid+".") {
+				supported = true
+			}
+		}
+		caps.MIBs[probe.name] = supported
+	}
+	caps.CheckedAt = time.Now()
+	return caps, nil
+}
+
+// capabilityCache holds the last probed capabilities of each device by name
+type capabilityCache struct {
+	mu      sync.RWMutex
+	devices map[string]DeviceCapabilities
+}
+
+func newCapabilityCache() *capabilityCache {
+	return &capabilityCache{devices: make(map[string]DeviceCapabilities)}
+}
+
+func (c *capabilityCache) get(name string) (DeviceCapabilities, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	caps, ok := c.devices[name]
+	return caps, ok
+}
+
+func (c *capabilityCache) set(name string, caps DeviceCapabilities) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.devices[name] = caps
+}
+
+func (c *capabilityCache) delete(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.devices, name)
+}
+
+// GetCapabilityRefresh returns how often pollers re-probe device capabilities, or 0 if they don't
+func (d DefaultsConfig) GetCapabilityRefresh() time.Duration {
+	switch {
+	case d.CapabilityRefreshSec < 0:
+		return 0
+	case d.CapabilityRefreshSec == 0:
+		return defaultCapabilityRefresh
+	}
+	return time.Duration(d.CapabilityRefreshSec) * time.Second
+}
+
+// refreshCapabilities probes the device's capabilities over the poll session when they are
+// due. It is called by the polling goroutine after a successful poll.
+func (p *Poller) refreshCapabilities() {
+	if p.capabilities == nil || p.capabilityRefresh <= 0 || p.session == nil {
+		return
+	}
+	now := time.Now()
+	if now.Before(p.nextProbe) {
+		return
+	}
+	p.nextProbe = now.Add(p.capabilityRefresh)
+
+	caps, err := probeCapabilities(p.session)
+	if err != nil {
+		log.Printf("Capability probe failed for %s: %v", p.device.Name, err)
+		return
+	}
+	p.capabilities.set(p.device.Name, caps)
+}
+
+// GetCapabilities returns the last probed capabilities of a device
+func (a *Agent) GetCapabilities(name string) (DeviceCapabilities, bool) {
+	return a.capabilities.get(name)
+}
+
+// handleDeviceCapabilities returns which standard MIBs a device supports, as last probed
+// by its poller or by discovery
+func (ws *WebServer) handleDeviceCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		ws.sendJSONError(w, "Device name is required", fmt.Errorf("missing name parameter"), http.StatusBadRequest)
+		return
+	}
+
+	caps, ok := ws.agent.GetCapabilities(name)
+	if !ok {
+		ws.sendJSONError(w, "Capabilities not known", fmt.Errorf("device %q has not been probed yet", name), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(caps)
+}
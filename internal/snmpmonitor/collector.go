@@ -0,0 +1,58 @@
+package snmpmonitor
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/henrygd/beszel"
+	"github.com/henrygd/beszel/internal/entities/system"
+)
+
+// collectorName returns the name the monitor uses for its own entry on the hub
+func collectorName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+	return hostname + " (snmp-monitor)"
+}
+
+// collectorData builds the synthetic system data the monitor reports about itself:
+// how many devices it watches, how many of them are up, and its own resource usage.
+func (a *Agent) collectorData() *system.CombinedData {
+	var total, up int
+	a.pollersMu.RLock()
+	for _, poller := range a.pollers {
+		total++
+		if poller.IsUp() {
+			up++
+		}
+	}
+	a.pollersMu.RUnlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return &system.CombinedData{
+		Stats: system.Stats{
+			MemUsed: float64(mem.Sys) / (1 << 30),
+		},
+		Info: system.Info{
+			Hostname:     collectorName(),
+			Cores:        runtime.NumCPU(),
+			Uptime:       uint64(time.Since(a.startedAt).Seconds()),
+			AgentType:    "snmp",
+			AgentVersion: beszel.Version,
+			ExtraInfo: map[string]string{
+				"devices_total": strconv.Itoa(total),
+				"devices_up":    strconv.Itoa(up),
+				"devices_down":  strconv.Itoa(total - up),
+				"goroutines":    strconv.Itoa(runtime.NumGoroutine()),
+				"collector_id":  a.hubConfig.GetCollectorID(),
+			},
+		},
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
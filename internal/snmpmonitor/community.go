@@ -0,0 +1,76 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// sysUpTime is answered by every SNMP agent, so it is used to test community strings
+const sysUpTime = ".1.3.6.1.2.1.1.3.0"
+
+// currentCommunity returns the community the poller connects with: the one that last
+// worked, or the device's first community
+func (p *Poller) currentCommunity() string {
+	if p.community != "" {
+		return p.community
+	}
+	if len(p.communities) > 0 {
+		return p.communities[0]
+	}
+	return ""
+}
+
+// findCommunity is called after a failed request. It tries the device's other communities
+// in order and keeps the session on the first one the device answers, returning true so
+// the request can be retried. Without another working community the session is left as it was.
+func (p *Poller) findCommunity(params *gosnmp.GoSNMP) bool {
+	if p.device.SNMPv3 != nil {
+		return false
+	}
+	i, ok := switchCommunity(params, p.communities)
+	if !ok {
+		return false
+	}
+	p.community = params.Community
+	// The community itself is a secret, so only its position is logged
+	log.Printf("Device %s answered community %d of %d, using it for subsequent polls", p.device.Name, i+1, len(p.communities))
+	return true
+}
+
+// switchCommunity tries the communities other than the session's current one in order and
+// keeps the session on the first one the device answers, returning its index. Without
+// another working community the session is left as it was.
+func switchCommunity(params *gosnmp.GoSNMP, communities []string) (int, bool) {
+	failed := params.Community
+	for i, community := range communities {
+		if community == failed {
+			continue
+		}
+		params.Community = community
+		if checkSession(params) == nil {
+			return i, true
+		}
+	}
+	params.Community = failed
+	return -1, false
+}
+
+// checkSession requests sysUpTime to test whether the device answers the session's credentials
+func checkSession(params *gosnmp.GoSNMP) error {
+	result, err := params.Get([]string{sysUpTime})
+	if err != nil {
+		return err
+	}
+	if result.Error != gosnmp.NoError {
+		return &pduError{status: result.Error}
+	}
+	if len(result.Variables) == 0 {
+		return fmt.Errorf("no value for sysUpTime")
+	}
+	if pduType := result.Variables[0].Type; pduType == gosnmp.NoSuchObject || pduType == gosnmp.NoSuchInstance {
+		return fmt.Errorf("no value for sysUpTime")
+	}
+	return nil
+}
@@ -1,10 +1,19 @@
 package snmpmonitor
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,7 +21,40 @@ import (
 type Config struct {
 	Hub       *HubConfig       `json:"hub,omitempty"`
 	WebServer *WebServerConfig `json:"web_server,omitempty"`
+	Defaults  *DefaultsConfig  `json:"defaults,omitempty"`
 	Devices   []DeviceConfig   `json:"devices"`
+	// Influx writes every polled metric to InfluxDB as well as the hub
+	Influx *InfluxConfig `json:"influx,omitempty"`
+	// MQTT publishes every polled metric to an MQTT broker as well as the hub
+	MQTT *MQTTConfig `json:"mqtt,omitempty"`
+}
+
+// DefaultsConfig defines settings that apply to all devices
+type DefaultsConfig struct {
+	// OnDuplicateOID controls what happens when a device lists the same OID under
+	// several metric keys: "merge" (default) keeps one and logs a warning, "error" rejects the config
+	OnDuplicateOID string `json:"on_duplicate_oid,omitempty"`
+	// OnDuplicateFingerprint controls what happens when devices would share one hub entry:
+	// "warn" (default) logs a warning, "error" rejects the config
+	OnDuplicateFingerprint string `json:"on_duplicate_fingerprint,omitempty"`
+	// IncludeOIDInPayload sends the source OID of each metric to the hub in the system's extra info
+	IncludeOIDInPayload bool `json:"include_oid_in_payload,omitempty"`
+	// MaxConcurrentPolls caps how many devices are polled at the same time. 0 means no limit.
+	MaxConcurrentPolls int `json:"max_concurrent_polls,omitempty"`
+	// Communities are used by every device after its own community and communities
+	Communities []string `json:"communities,omitempty"`
+	// CapabilityRefreshSec is how often each poller probes which standard MIBs its device
+	// supports, in seconds. 0 uses one hour and a negative value disables the probes.
+	CapabilityRefreshSec int `json:"capability_refresh_sec,omitempty"`
+	// HistorySize is how many recent samples of each metric are kept in memory for
+	// /api/device/stats. 0 keeps no history.
+	HistorySize int `json:"history_size,omitempty"`
+	// RoundDigits is the number of decimals metrics without rounding settings of their own
+	// are rounded to. -1 or unset leaves their values unrounded.
+	RoundDigits *int `json:"round_digits,omitempty"`
+	// HistoryGaps records a gap marker in a metric's history for every poll cycle that
+	// produced no value for it, instead of leaving no trace of the missed cycle
+	HistoryGaps bool `json:"history_gaps,omitempty"`
 }
 
 // HubConfig defines the hub connection settings
@@ -20,20 +62,159 @@ type HubConfig struct {
 	URL   string `json:"url"`
 	Token string `json:"token"`
 	Key   string `json:"key"`
+	// ReportSelf registers the monitor itself on the hub as a collector device
+	ReportSelf bool `json:"report_self,omitempty"`
+	// ConnectTimeout bounds dialing and the WebSocket handshake with the hub, in seconds
+	ConnectTimeout int `json:"connect_timeout_sec,omitempty"`
+	// SkipSignatureVerification trusts the hub without checking its signature, for older hubs that don't sign
+	SkipSignatureVerification bool `json:"skip_signature_verification,omitempty"`
+	// VerifyAtStartup makes startup fail if the hub does not answer an HTTP request
+	VerifyAtStartup bool `json:"verify_at_startup,omitempty"`
+	// MaxSendFailures is how many consecutive failed writes to the hub force a device's connection
+	// to be closed and re-established
+	MaxSendFailures int `json:"max_send_failures,omitempty"`
+	// CollectorID identifies this monitor in the extra info of every system it reports, so
+	// devices watched by more than one collector can be traced. Defaults to the hostname.
+	CollectorID string `json:"collector_id,omitempty"`
+}
+
+// GetConnectTimeout returns the hub connection establishment timeout
+func (h *HubConfig) GetConnectTimeout() time.Duration {
+	if h.ConnectTimeout <= 0 {
+		return 10 * time.Second // default 10 seconds
+	}
+	return time.Duration(h.ConnectTimeout) * time.Second
+}
+
+// GetMaxSendFailures returns the number of consecutive send failures after which a hub connection is reset
+func (h *HubConfig) GetMaxSendFailures() int {
+	if h.MaxSendFailures <= 0 {
+		return 3
+	}
+	return h.MaxSendFailures
+}
+
+// GetCollectorID returns the configured collector ID, or the hostname if none is set
+func (h *HubConfig) GetCollectorID() string {
+	if id := strings.TrimSpace(h.CollectorID); id != "" {
+		return id
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "localhost"
+	}
+	return hostname
 }
 
 // WebServerConfig defines the web server settings
 type WebServerConfig struct {
-	Port int `json:"port"`
+	// Port is the port to listen on. 0 set explicitly disables the web server.
+	Port int `json:"port,omitempty"`
+	// BindAddr is the IP address to listen on. Empty listens on all interfaces.
+	BindAddr string `json:"bind_addr,omitempty"`
+	// ReadOnly serves the status UI and read-only endpoints but rejects any configuration change
+	ReadOnly bool `json:"readonly,omitempty"`
+	// StatusCacheSec serves /api/status from a snapshot refreshed at this interval instead of
+	// reading every poller on each request. 0 disables the cache.
+	StatusCacheSec int `json:"status_cache_sec,omitempty"`
+	// Auth requires credentials for every request. Without it the web server is open to anyone who can reach it.
+	Auth *WebAuthConfig `json:"auth,omitempty"`
+	// TLSCertFile and TLSKeyFile serve the web server over HTTPS when both are set
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+	// RuntimeMetrics adds the monitor's own goroutine, memory and GC metrics to /metrics
+	RuntimeMetrics bool `json:"runtime_metrics,omitempty"`
+	// MaxBodyBytes limits the size of request bodies. Larger requests are rejected with 413.
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+	// Required stops the monitor at startup when the web server can't listen, instead of
+	// polling without it
+	Required bool `json:"required,omitempty"`
+}
+
+// Enabled reports whether the web server is started
+func (w *WebServerConfig) Enabled() bool {
+	return w.Port > 0
+}
+
+// validateBindAddr checks that the bind address is empty, localhost or an IP address
+func (w *WebServerConfig) validateBindAddr() error {
+	if w.BindAddr == "" || w.BindAddr == "localhost" {
+		return nil
+	}
+	if net.ParseIP(w.BindAddr) == nil {
+		return fmt.Errorf("invalid web_server bind_addr %q: must be an IP address or localhost", w.BindAddr)
+	}
+	return nil
+}
+
+// validateTLS checks that the TLS certificate and key are either both set or both empty
+func (w *WebServerConfig) validateTLS() error {
+	if (w.TLSCertFile == "") != (w.TLSKeyFile == "") {
+		return fmt.Errorf("web_server tls_cert_file and tls_key_file must be set together")
+	}
+	return nil
+}
+
+// UseTLS reports whether the web server is served over HTTPS
+func (w *WebServerConfig) UseTLS() bool {
+	return w.TLSCertFile != "" && w.TLSKeyFile != ""
+}
+
+// Addr returns the host:port address the web server listens on
+func (w *WebServerConfig) Addr() string {
+	return net.JoinHostPort(w.BindAddr, strconv.Itoa(w.Port))
+}
+
+// GetMaxBodyBytes returns the largest request body the web server accepts
+func (w *WebServerConfig) GetMaxBodyBytes() int64 {
+	if w.MaxBodyBytes <= 0 {
+		return 4 << 20 // default 4 MiB
+	}
+	return w.MaxBodyBytes
+}
+
+// GetStatusCacheInterval returns how often the status snapshot is refreshed, or 0 if caching is disabled
+func (w *WebServerConfig) GetStatusCacheInterval() time.Duration {
+	if w.StatusCacheSec <= 0 {
+		return 0
+	}
+	return time.Duration(w.StatusCacheSec) * time.Second
 }
 
 // DeviceConfig defines a device to monitor
 type DeviceConfig struct {
-	Name         string                  `json:"name"`
-	IP           string                  `json:"ip"`
-	Community    string                  `json:"community"`
-	PollInterval int                     `json:"poll_interval_sec"` // in seconds
-	Metrics      map[string]MetricConfig `json:"metrics"`
+	Name         string `json:"name"`
+	IP           string `json:"ip"`
+	Community    string `json:"community"`
+	PollInterval int    `json:"poll_interval_sec"`           // in seconds
+	SendInterval int    `json:"send_interval_sec,omitempty"` // in seconds, 0 sends after every poll
+	BackoffMax   int    `json:"backoff_max_sec,omitempty"`   // in seconds, 0 disables backoff
+	// Communities lists further community strings after Community
+	Communities []string `json:"communities,omitempty"`
+	// Port is the device's SNMP port, 161 when zero
+	Port int `json:"port,omitempty"`
+	// Mode "entity-sensors" discovers metrics from the device's ENTITY-SENSOR-MIB and
+	// "interfaces" reports interface bandwidth from the IF-MIB, instead of Metrics
+	Mode string `json:"mode,omitempty"`
+	// SNMPv3 switches the device to SNMPv3 with user-based security; community is then ignored
+	SNMPv3 *SNMPv3Config `json:"snmpv3,omitempty"`
+	// Disabled keeps the device in the configuration without polling it or connecting it to the hub
+	Disabled bool `json:"disabled,omitempty"`
+	// BisectOnError retries a failed GET as two smaller batches to isolate a misbehaving OID
+	BisectOnError bool `json:"bisect_on_error,omitempty"`
+	// UseGetBulk polls with GETBULK when the device has more than GetBulkThreshold metrics
+	UseGetBulk        bool `json:"use_getbulk,omitempty"`
+	GetBulkThreshold  int  `json:"getbulk_threshold,omitempty"`
+	NonRepeaters      int  `json:"non_repeaters,omitempty"`
+	MaxRepetitions    int  `json:"max_repetitions,omitempty"`      // default 1
+	MaxOIDsPerRequest int  `json:"max_oids_per_request,omitempty"` // default 60
+	// RetryTimeouts is an escalating list of request timeouts in seconds, e.g. [1, 3, 5]
+	RetryTimeouts []int                   `json:"retry_timeouts,omitempty"`
+	Metrics       map[string]MetricConfig `json:"metrics"`
+	// Synthetic defines metrics aggregated from the polled metrics after each poll
+	Synthetic map[string]SyntheticMetric `json:"synthetic,omitempty"`
+	// Interfaces selects the interfaces reported in interfaces mode
+	Interfaces *InterfacesConfig `json:"interfaces,omitempty"`
 }
 
 // MetricConfig defines how to poll and interpret an OID
@@ -43,6 +224,42 @@ type MetricConfig struct {
 	Unit     string  `json:"unit"`
 	Category string  `json:"category"`
 	Scale    float64 `json:"scale"`
+	// ScaleMode is multiply (default), which multiplies raw values by Scale, or divide
+	ScaleMode string `json:"scale_mode,omitempty"`
+	// Kind is gauge (default), counter, counter32 or counter64. Counters are sent as a per-second rate.
+	Kind string `json:"kind,omitempty"`
+	// Repeating marks the OID as a row of a table column. With use_getbulk, the rows of
+	// a column are fetched together as one GETBULK repeater instead of one OID each.
+	Repeating bool `json:"repeating,omitempty"`
+	// RegexExtract parses the value from the first capture group when the OID returns a string
+	RegexExtract string `json:"regex_extract,omitempty"`
+	// SensorGroup groups metrics that belong to the same physical sensor
+	SensorGroup string `json:"sensor_group,omitempty"`
+	// RoundMode selects how values are rounded: nearest (default), floor, ceil or trunc
+	RoundMode string `json:"round_mode,omitempty"`
+	// RoundDigits is the number of decimals to round to, or -1 for no rounding. Values are
+	// not rounded unless RoundMode, RoundDigits, Round1 or the default round_digits is set.
+	RoundDigits *int `json:"round_digits,omitempty"`
+	// Round1 is the older way of rounding to one decimal, kept as an alias for RoundDigits 1
+	Round1 bool `json:"round1,omitempty"`
+	// AlertAbove and AlertBelow switch the metric to event mode: it is only forwarded to the
+	// hub when it enters the alert range and once when it returns to normal
+	AlertAbove *float64 `json:"alert_above,omitempty"`
+	AlertBelow *float64 `json:"alert_below,omitempty"`
+	// EnumMap maps integer values to display labels, e.g. {"1": "on", "2": "off", "3": "fault"}.
+	// The numeric value is still sent and graphed; the label is shown alongside it.
+	EnumMap map[int]string `json:"enum_map,omitempty"`
+	// PollInterval overrides the device's poll interval for this metric, in seconds
+	PollInterval int `json:"poll_interval_sec,omitempty"`
+	// Walk reads the table under OID and reports one metric per row, with the row index
+	// appended to the metric name. At most MaxRows rows are read (default 100).
+	Walk    bool `json:"walk,omitempty"`
+	MaxRows int  `json:"max_rows,omitempty"`
+	// LabelOID is a column of the same table, such as ifName, whose value names each walked row
+	LabelOID string `json:"label_oid,omitempty"`
+	// WrapThreshold is the fraction of a counter's range within which a decrease is taken as
+	// a wrap rather than a reset (default 0.1)
+	WrapThreshold float64 `json:"wrap_threshold,omitempty"`
 }
 
 // DeviceData represents data to send to the hub
@@ -58,6 +275,42 @@ type MetricValue struct {
 	Value    float64 `json:"value"`
 	Unit     string  `json:"unit"`
 	Category string  `json:"category"`
+	Group    string  `json:"group,omitempty"`
+	// OID is the source OID, set when include_oid_in_payload is enabled
+	OID string `json:"oid,omitempty"`
+	// Label is the enum_map label of the value, if any
+	Label string `json:"label,omitempty"`
+}
+
+// parseConfig decodes a configuration file. Unknown fields, which are usually typos, are
+// rejected unless strict is false, in which case they are logged and ignored so a config
+// written for a newer version still loads.
+func parseConfig(data []byte, strict bool) (Config, error) {
+	var config Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&config)
+	if err == nil || strict || !strings.HasPrefix(err.Error(), "json: unknown field") {
+		return config, err
+	}
+
+	log.Printf("Ignoring unknown config field (BESZEL_CONFIG_STRICT=false): %v", err)
+	config = Config{}
+	err = json.Unmarshal(data, &config)
+	return config, err
+}
+
+// webServerPortIsZero reports whether a configuration file sets web_server.port to 0,
+// which disables the web server, as opposed to leaving it out to use the default port
+func webServerPortIsZero(data []byte) bool {
+	var raw struct {
+		WebServer map[string]json.RawMessage `json:"web_server"`
+	}
+	if json.Unmarshal(data, &raw) != nil {
+		return false
+	}
+	port, ok := raw.WebServer["port"]
+	return ok && strings.TrimSpace(string(port)) == "0"
 }
 
 // LoadConfig loads the configuration from a JSON file and environment variables
@@ -67,8 +320,9 @@ func LoadConfig(path string) (*Config, *HubConfig, *WebServerConfig, error) {
 		return nil, nil, nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	strict := os.Getenv("BESZEL_CONFIG_STRICT") != "false"
+	config, err := parseConfig(data, strict)
+	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
@@ -84,11 +338,19 @@ func LoadConfig(path string) (*Config, *HubConfig, *WebServerConfig, error) {
 			Token: os.Getenv("BESZEL_HUB_TOKEN"),
 			Key:   os.Getenv("BESZEL_HUB_KEY"),
 		}
+		if config.Hub != nil {
+			hubConfig.ReportSelf = config.Hub.ReportSelf
+			hubConfig.ConnectTimeout = config.Hub.ConnectTimeout
+			hubConfig.SkipSignatureVerification = config.Hub.SkipSignatureVerification
+			hubConfig.VerifyAtStartup = config.Hub.VerifyAtStartup
+			hubConfig.MaxSendFailures = config.Hub.MaxSendFailures
+			hubConfig.CollectorID = config.Hub.CollectorID
+		}
 	}
 
 	// Load web server config - use web config if available, otherwise fall back to environment variables
 	webServerConfig := &WebServerConfig{}
-	if config.WebServer != nil && config.WebServer.Port > 0 {
+	if config.WebServer != nil && (config.WebServer.Port > 0 || webServerPortIsZero(data)) {
 		// Use web interface config
 		webServerConfig = config.WebServer
 	} else {
@@ -97,29 +359,320 @@ func LoadConfig(path string) (*Config, *HubConfig, *WebServerConfig, error) {
 			Port: 6655, // Default port
 		}
 		if portStr := os.Getenv("BESZEL_WEB_PORT"); portStr != "" {
-			if port, err := strconv.Atoi(portStr); err == nil && port > 0 {
+			if port, err := strconv.Atoi(portStr); err == nil && port >= 0 {
 				webServerConfig.Port = port
 			}
 		}
+		if config.WebServer != nil {
+			webServerConfig.ReadOnly = config.WebServer.ReadOnly
+			webServerConfig.StatusCacheSec = config.WebServer.StatusCacheSec
+			webServerConfig.BindAddr = config.WebServer.BindAddr
+			webServerConfig.Auth = config.WebServer.Auth
+			webServerConfig.TLSCertFile = config.WebServer.TLSCertFile
+			webServerConfig.TLSKeyFile = config.WebServer.TLSKeyFile
+			webServerConfig.RuntimeMetrics = config.WebServer.RuntimeMetrics
+			webServerConfig.MaxBodyBytes = config.WebServer.MaxBodyBytes
+			webServerConfig.Required = config.WebServer.Required
+		}
+		if readOnly := os.Getenv("BESZEL_WEB_READONLY"); readOnly == "true" {
+			webServerConfig.ReadOnly = true
+		}
+		if bindAddr := os.Getenv("BESZEL_WEB_BIND_ADDR"); bindAddr != "" {
+			webServerConfig.BindAddr = bindAddr
+		}
+	}
+	if webServerConfig.Required && !webServerConfig.Enabled() {
+		return nil, nil, nil, fmt.Errorf("web_server.required is set but the web server is disabled by port 0")
+	}
+	if err := webServerConfig.validateBindAddr(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := webServerConfig.validateTLS(); err != nil {
+		return nil, nil, nil, err
+	}
+	if webServerConfig.Auth != nil {
+		if err := webServerConfig.Auth.validate(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if err := config.Influx.validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := config.MQTT.validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := config.dedupeOIDs(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := config.validateMetrics(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := config.checkHubConflicts(); err != nil {
+		return nil, nil, nil, err
 	}
+	config.warnExtremeScales()
 
 	return &config, hubConfig, webServerConfig, nil
 }
 
-// SaveConfig saves the configuration to a JSON file
+// GetDefaults returns the device defaults, or zero values if none are configured
+func (c *Config) GetDefaults() DefaultsConfig {
+	if c.Defaults == nil {
+		return DefaultsConfig{}
+	}
+	return *c.Defaults
+}
+
+// dedupeOIDs handles metrics that poll the same OID more than once within a device
+// according to the OnDuplicateOID policy. Only exact copies count as duplicates: metrics
+// that read the same OID with a different extraction, scaling or schedule are kept.
+// When merging, the metric with the alphabetically first key is kept.
+func (c *Config) dedupeOIDs() error {
+	policy := c.GetDefaults().OnDuplicateOID
+	if policy != "" && policy != "merge" && policy != "error" {
+		return fmt.Errorf("invalid on_duplicate_oid %q: must be \"merge\" or \"error\"", policy)
+	}
+
+	for i := range c.Devices {
+		device := &c.Devices[i]
+
+		keys := make([]string, 0, len(device.Metrics))
+		for key := range device.Metrics {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		seen := make(map[string][]string, len(keys))
+		for _, key := range keys {
+			metric := device.Metrics[key]
+			first := ""
+			for _, kept := range seen[metric.OID] {
+				if sameMetric(device.Metrics[kept], metric) {
+					first = kept
+					break
+				}
+			}
+			if first == "" {
+				seen[metric.OID] = append(seen[metric.OID], key)
+				continue
+			}
+			if policy == "error" {
+				return fmt.Errorf("device %s: metrics %q and %q are duplicates of OID %s", device.Name, first, key, metric.OID)
+			}
+			log.Printf("Warning: device %s metrics %q and %q are duplicates of OID %s, ignoring %q", device.Name, first, key, metric.OID, key)
+			delete(device.Metrics, key)
+		}
+	}
+	return nil
+}
+
+// sameMetric reports whether two metrics are copies of each other, differing at most in
+// their display name
+func sameMetric(a, b MetricConfig) bool {
+	a.Name, b.Name = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+// scaleRange is the range of scale magnitudes considered plausible for a category
+type scaleRange struct {
+	min, max float64
+}
+
+// typicalScales holds plausible scale magnitudes for categories whose raw values
+// usually need little scaling. Other categories use defaultScaleRange.
+var typicalScales = map[string]scaleRange{
+	"temperature": {0.001, 10},
+	"humidity":    {0.001, 10},
+	"pressure":    {0.001, 100},
+	"co2":         {0.01, 100},
+}
+
+var defaultScaleRange = scaleRange{0.0001, 10000}
+
+// warnExtremeScales logs a warning for metric scales that are far outside the usual
+// range for their category, which is almost always a typo (e.g. 0.0001 instead of 10000).
+// The configuration is not rejected since the value may be intentional.
+func (c *Config) warnExtremeScales() {
+	for _, device := range c.Devices {
+		for key, metric := range device.Metrics {
+			if metric.Scale == 0 {
+				continue
+			}
+			r, ok := typicalScales[strings.ToLower(metric.Category)]
+			if !ok {
+				r = defaultScaleRange
+			}
+			if mag := math.Abs(metric.applyScale(1)); mag < r.min || mag > r.max {
+				log.Printf("Warning: device %s metric %s has an unusual scale %g for category %q (expected %g to %g)",
+					device.Name, key, metric.Scale, metric.Category, r.min, r.max)
+			}
+		}
+	}
+}
+
+// SaveConfig saves the configuration to a JSON file. The file is written to a temporary
+// file in the same directory and renamed over the original, so a crash mid-write
+// never leaves a truncated config behind.
 func (c *Config) SaveConfig(path string) error {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
 
 	return nil
 }
 
+// FindDevice returns the index of the device with the given name, or -1 if it does not exist
+func (c *Config) FindDevice(name string) int {
+	for i, device := range c.Devices {
+		if device.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Clone returns a copy of the configuration whose device list and metric maps can be modified
+// without affecting the original
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Devices = make([]DeviceConfig, len(c.Devices))
+	for i, device := range c.Devices {
+		metrics := make(map[string]MetricConfig, len(device.Metrics))
+		for key, metric := range device.Metrics {
+			metrics[key] = metric
+		}
+		device.Metrics = metrics
+		clone.Devices[i] = device
+	}
+	return &clone
+}
+
+// oidPattern matches a numeric OID with an optional leading dot
+var oidPattern = regexp.MustCompile(`^\.?\d+(\.\d+)+$`)
+
+// validateMetrics checks metric settings that can only be verified by parsing them
+func (c *Config) validateMetrics() error {
+	if size := c.GetDefaults().HistorySize; size < 0 || size > maxHistorySize {
+		return fmt.Errorf("history_size must be between 0 and %d", maxHistorySize)
+	}
+	if err := validateRoundDigits(c.GetDefaults().RoundDigits); err != nil {
+		return err
+	}
+	for _, device := range c.Devices {
+		for _, timeout := range device.RetryTimeouts {
+			if timeout <= 0 {
+				return fmt.Errorf("device %s: retry_timeouts must be positive", device.Name)
+			}
+		}
+		if device.Port < 0 || device.Port > 65535 {
+			return fmt.Errorf("device %s: port must be between 1 and 65535", device.Name)
+		}
+		if err := device.validateMode(); err != nil {
+			return fmt.Errorf("device %s: %w", device.Name, err)
+		}
+		if err := device.validateGetBulk(); err != nil {
+			return fmt.Errorf("device %s: %w", device.Name, err)
+		}
+		if device.SNMPv3 != nil {
+			if err := device.SNMPv3.validate(); err != nil {
+				return fmt.Errorf("device %s: %w", device.Name, err)
+			}
+		}
+		if err := device.validateSynthetic(); err != nil {
+			return fmt.Errorf("device %s: %w", device.Name, err)
+		}
+		for key, metric := range device.Metrics {
+			if _, err := metric.compileRegexExtract(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validateScale(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validateRounding(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validateKind(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validateAlert(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validatePollInterval(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+			if err := metric.validateWalk(); err != nil {
+				return fmt.Errorf("device %s, metric '%s': %w", device.Name, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compileRegexExtract compiles the metric's regex_extract pattern, returning nil if none is set
+func (m *MetricConfig) compileRegexExtract() (*regexp.Regexp, error) {
+	if m.RegexExtract == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(m.RegexExtract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex_extract: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("regex_extract %q must contain a capture group", m.RegexExtract)
+	}
+	return re, nil
+}
+
+// mergeCommunities returns the device's community strings in order: community, then
+// communities, then the default communities, without duplicates or empty strings
+func (d *DeviceConfig) mergeCommunities(defaults []string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range [][]string{{d.Community}, d.Communities, defaults} {
+		for _, community := range list {
+			if community != "" && !seen[community] {
+				seen[community] = true
+				merged = append(merged, community)
+			}
+		}
+	}
+	return merged
+}
+
+// GetPort returns the device's SNMP port
+func (d *DeviceConfig) GetPort() uint16 {
+	if d.Port == 0 {
+		return 161
+	}
+	return uint16(d.Port)
+}
+
 // GetPollInterval returns the poll interval for a device
 func (d *DeviceConfig) GetPollInterval() time.Duration {
 	if d.PollInterval <= 0 {
@@ -127,3 +680,33 @@ func (d *DeviceConfig) GetPollInterval() time.Duration {
 	}
 	return time.Duration(d.PollInterval) * time.Second
 }
+
+// GetSendInterval returns how often collected metrics are pushed to the hub.
+// It returns zero when metrics are pushed after every poll.
+func (d *DeviceConfig) GetSendInterval() time.Duration {
+	if d.SendInterval <= 0 {
+		return 0
+	}
+	return time.Duration(d.SendInterval) * time.Second
+}
+
+// GetRetryTimeouts returns the escalating request timeouts, or nil if none are configured
+func (d *DeviceConfig) GetRetryTimeouts() []time.Duration {
+	if len(d.RetryTimeouts) == 0 {
+		return nil
+	}
+	timeouts := make([]time.Duration, len(d.RetryTimeouts))
+	for i, timeout := range d.RetryTimeouts {
+		timeouts[i] = time.Duration(timeout) * time.Second
+	}
+	return timeouts
+}
+
+// GetBackoffMax returns the longest poll interval used while a device is unreachable.
+// It returns zero when adaptive backoff is disabled.
+func (d *DeviceConfig) GetBackoffMax() time.Duration {
+	if d.BackoffMax <= 0 {
+		return 0
+	}
+	return time.Duration(d.BackoffMax) * time.Second
+}
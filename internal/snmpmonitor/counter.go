@@ -0,0 +1,131 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Metric kinds. Counter32 and counter64 fix the counter width; plain counter takes it from
+// the type the device returns.
+const (
+	KindGauge     = "gauge"
+	KindCounter   = "counter"
+	KindCounter32 = "counter32"
+	KindCounter64 = "counter64"
+)
+
+// defaultWrapThreshold is the fraction of a counter's range within which a decrease is taken as a wrap
+const defaultWrapThreshold = 0.1
+
+// counterSample is the previous raw reading of a counter metric
+type counterSample struct {
+	value uint64
+	at    time.Time
+}
+
+// validateKind checks the metric's kind setting
+func (m *MetricConfig) validateKind() error {
+	switch m.Kind {
+	case "", KindGauge:
+		if m.WrapThreshold != 0 {
+			return fmt.Errorf("wrap_threshold requires a counter kind")
+		}
+	case KindCounter, KindCounter32, KindCounter64:
+		if m.RegexExtract != "" {
+			return fmt.Errorf("regex_extract cannot be used with kind %q", m.Kind)
+		}
+		if m.WrapThreshold < 0 || m.WrapThreshold >= 1 {
+			return fmt.Errorf("wrap_threshold must be at least 0 and less than 1")
+		}
+	default:
+		return fmt.Errorf("invalid kind %q: must be gauge, counter, counter32 or counter64", m.Kind)
+	}
+	return nil
+}
+
+// isCounter reports whether the metric is sent as a per-second rate
+func (m *MetricConfig) isCounter() bool {
+	return m.Kind == KindCounter || m.Kind == KindCounter32 || m.Kind == KindCounter64
+}
+
+// counterWidth returns the bit width of the counter, from the metric's kind or else from
+// the returned type, or 0 if it is unknown
+func (m *MetricConfig) counterWidth(pduType gosnmp.Asn1BER) int {
+	switch {
+	case m.Kind == KindCounter32, m.Kind == KindCounter && pduType == gosnmp.Counter32:
+		return 32
+	case m.Kind == KindCounter64, m.Kind == KindCounter && pduType == gosnmp.Counter64:
+		return 64
+	}
+	return 0
+}
+
+// GetWrapThreshold returns the fraction of the counter's range within which a decrease is taken as a wrap
+func (m *MetricConfig) GetWrapThreshold() float64 {
+	if m.WrapThreshold <= 0 {
+		return defaultWrapThreshold
+	}
+	return m.WrapThreshold
+}
+
+// counterDelta returns how far a counter of the given bit width advanced from previous to
+// current. A decrease is a wrap if the distance travelled through the counter's maximum is
+// within threshold of its range, meaning the previous reading was near the maximum and the
+// current one near zero. Any other decrease is a reset, such as a device restart, and
+// returns false. Counters of unknown width are never taken to have wrapped.
+func counterDelta(previous, current uint64, width int, threshold float64) (uint64, bool) {
+	if current >= previous {
+		return current - previous, true
+	}
+	var max uint64
+	switch width {
+	case 32:
+		if previous > math.MaxUint32 {
+			return 0, false
+		}
+		max = math.MaxUint32
+	case 64:
+		max = math.MaxUint64
+	default:
+		return 0, false
+	}
+
+	delta := max - previous + current + 1
+	if float64(delta) > threshold*float64(max) {
+		return 0, false
+	}
+	return delta, true
+}
+
+// counterRate returns the per-second rate of a counter metric since its previous reading.
+// It returns nil on the first reading, when there is no baseline yet, and when the
+// counter was reset. The new reading becomes the baseline either way.
+func (p *Poller) counterRate(name string, metric MetricConfig, variable gosnmp.SnmpPDU, at time.Time) *float64 {
+	if convertSNMPValue(variable.Value) == nil {
+		return nil
+	}
+	current := gosnmp.ToBigInt(variable.Value).Uint64()
+
+	p.mu.Lock()
+	previous, ok := p.counters[name]
+	p.counters[name] = counterSample{value: current, at: at}
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	elapsed := at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+
+	delta, ok := counterDelta(previous.value, current, metric.counterWidth(variable.Type), metric.GetWrapThreshold())
+	if !ok {
+		return nil
+	}
+	rate := float64(delta) / elapsed
+	return &rate
+}
@@ -0,0 +1,86 @@
+package snmpmonitor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current uint64
+		width             int
+		threshold         float64
+		delta             uint64
+		ok                bool
+	}{
+		{"increase", 100, 250, 32, defaultWrapThreshold, 150, true},
+		{"unchanged", 100, 100, 64, defaultWrapThreshold, 0, true},
+		{"32-bit wrap", math.MaxUint32 - 9, 5, 32, defaultWrapThreshold, 15, true},
+		{"64-bit wrap", math.MaxUint64 - 99, 100, 64, defaultWrapThreshold, 200, true},
+		{"32-bit reset", 3_000_000_000, 10, 32, defaultWrapThreshold, 0, false},
+		{"64-bit reset", 1 << 40, 10, 64, defaultWrapThreshold, 0, false},
+		// a 32-bit counter can't have held a value above its maximum
+		{"32-bit from out of range value", 1 << 33, 10, 32, defaultWrapThreshold, 0, false},
+		{"unknown width", math.MaxUint32 - 9, 5, 0, defaultWrapThreshold, 0, false},
+		// the same decrease is a wrap or a reset depending on the threshold
+		{"wide threshold wrap", 3_000_000_000, 10, 32, 0.5, 1<<32 - 3_000_000_000 + 10, true},
+		{"narrow threshold reset", math.MaxUint32 - 1_000_000, 0, 32, 0.0001, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, ok := counterDelta(tt.previous, tt.current, tt.width, tt.threshold)
+			if delta != tt.delta || ok != tt.ok {
+				t.Errorf("counterDelta(%d, %d, %d, %v) = %d, %v, want %d, %v",
+					tt.previous, tt.current, tt.width, tt.threshold, delta, ok, tt.delta, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCounterRateWrapAndReset(t *testing.T) {
+	p := &Poller{counters: make(map[string]counterSample)}
+	metric := MetricConfig{Kind: KindCounter}
+	start := time.Now()
+	sample := func(value uint32, offset time.Duration) *float64 {
+		return p.counterRate("octets", metric, gosnmp.SnmpPDU{Type: gosnmp.Counter32, Value: uint(value)}, start.Add(offset))
+	}
+
+	if rate := sample(math.MaxUint32-99, 0); rate != nil {
+		t.Fatalf("first reading: got rate %v, want none", *rate)
+	}
+	// wrapped through the maximum: 100 to reach zero plus 100 more
+	if rate := sample(100, 10*time.Second); rate == nil || *rate != 20 {
+		t.Fatalf("after wrap: got %v, want rate 20", rate)
+	}
+	// the device restarted: no rate, and the new reading becomes the baseline
+	if rate := sample(5, 20*time.Second); rate != nil {
+		t.Fatalf("after reset: got rate %v, want none", *rate)
+	}
+	if rate := sample(55, 30*time.Second); rate == nil || *rate != 5 {
+		t.Fatalf("after new baseline: got %v, want rate 5", rate)
+	}
+}
+
+func TestCounterWidthFromKind(t *testing.T) {
+	tests := []struct {
+		kind    string
+		pduType gosnmp.Asn1BER
+		width   int
+	}{
+		{KindCounter, gosnmp.Counter32, 32},
+		{KindCounter, gosnmp.Counter64, 64},
+		{KindCounter, gosnmp.Integer, 0},
+		{KindCounter32, gosnmp.Integer, 32},
+		{KindCounter64, gosnmp.Gauge32, 64},
+	}
+	for _, tt := range tests {
+		metric := MetricConfig{Kind: tt.kind}
+		if width := metric.counterWidth(tt.pduType); width != tt.width {
+			t.Errorf("kind %s with %v: got width %d, want %d", tt.kind, tt.pduType, width, tt.width)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package snmpmonitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// discoveryTable is a well-known sensor table column that discovery walks
+type discoveryTable struct {
+	// key prefixes the suggested metric keys, which end in the row index
+	key string
+	// value is the column holding the readings and label the column naming each row
+	value string
+	label string
+	// name is used with the row index when a row has no label
+	name     string
+	unit     string
+	category string
+	scale    float64
+}
+
+// discoveryTables lists the sensor tables discovery looks for besides the ENTITY-SENSOR-MIB
+var discoveryTables = []discoveryTable{
+	// LM-SENSORS-MIB (net-snmp on Linux hosts); temperatures in m°C, voltages in mV
+	{key: "lm_temp", value: ".1.3.6.1.4.1.2021.13.16.2.1.3", label: ".1.3.6.1.4.1.2021.13.16.2.1.2", name: "Temperature", unit: "°C", category: "temperature", scale: 0.001},
+	{key: "lm_fan", value: ".1.3.6.1.4.1.2021.13.16.3.1.3", label: ".1.3.6.1.4.1.2021.13.16.3.1.2", name: "Fan", unit: "RPM", category: "fan", scale: 1},
+	{key: "lm_volt", value: ".1.3.6.1.4.1.2021.13.16.4.1.3", label: ".1.3.6.1.4.1.2021.13.16.4.1.2", name: "Voltage", unit: "V", category: "voltage", scale: 0.001},
+	// CISCO-ENVMON-MIB; voltages in mV
+	{key: "cisco_temp", value: ".1.3.6.1.4.1.9.9.13.1.3.1.3", label: ".1.3.6.1.4.1.9.9.13.1.3.1.2", name: "Temperature", unit: "°C", category: "temperature", scale: 1},
+	{key: "cisco_volt", value: ".1.3.6.1.4.1.9.9.13.1.2.1.3", label: ".1.3.6.1.4.1.9.9.13.1.2.1.2", name: "Voltage", unit: "V", category: "voltage", scale: 0.001},
+	// PowerNet-MIB (APC UPS)
+	{key: "apc_battery_temp", value: ".1.3.6.1.4.1.318.1.1.1.2.2.2", name: "Battery Temperature", unit: "°C", category: "temperature", scale: 1},
+	{key: "apc_battery_capacity", value: ".1.3.6.1.4.1.318.1.1.1.2.2.1", name: "Battery Capacity", unit: "%", category: "battery", scale: 1},
+	{key: "apc_output_load", value: ".1.3.6.1.4.1.318.1.1.1.4.2.3", name: "Output Load", unit: "%", category: "load", scale: 1},
+}
+
+// discoveryResult holds the metrics suggested for a device. Metrics has the shape of a
+// device's metrics map so entries can be copied into the configuration as they are.
+type discoveryResult struct {
+	Metrics map[string]MetricConfig `json:"metrics"`
+	// Values holds the current reading of each suggested metric, scaled the way a poller
+	// would scale it with the suggested configuration
+	Values map[string]float64 `json:"values"`
+	// Errors lists tables that could not be walked
+	Errors []string `json:"errors,omitempty"`
+	// Capabilities lists which standard MIBs the device supports
+	Capabilities *DeviceCapabilities `json:"capabilities,omitempty"`
+}
+
+// handleDiscover walks well-known sensor tables on a device that need not be configured
+// yet and suggests a metric for every reading found. The body is a device configuration
+// with at least an IP; community, communities, port and snmpv3 are used to connect.
+// The device's capabilities are probed too and cached under its name, if it has one.
+func (ws *WebServer) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ws.readRequestBody(w, r)
+	if err != nil {
+		ws.sendBodyError(w, err)
+		return
+	}
+
+	var device DeviceConfig
+	if err := json.Unmarshal(body, &device); err != nil {
+		ws.sendJSONError(w, "Failed to parse request", err, http.StatusBadRequest)
+		return
+	}
+	if device.IP == "" {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("ip is required"), http.StatusBadRequest)
+		return
+	}
+	if device.SNMPv3 != nil {
+		if err := device.SNMPv3.validate(); err != nil {
+			ws.sendJSONError(w, "Invalid snmpv3 settings", err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	params, err := ws.connectDevice(&device)
+	if err != nil {
+		ws.sendJSONError(w, "Failed to connect to device", err, http.StatusBadGateway)
+		return
+	}
+	defer params.Conn.Close()
+
+	result := discover(params)
+	if len(result.Metrics) == 0 && len(result.Errors) > 0 {
+		ws.sendJSONError(w, "Discovery failed", errors.New(result.Errors[0]), http.StatusBadGateway)
+		return
+	}
+	if caps, err := probeCapabilities(params); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	} else {
+		result.Capabilities = &caps
+		if device.Name != "" {
+			ws.agent.capabilities.set(device.Name, caps)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// discover walks the ENTITY-SENSOR-MIB and the discovery tables, reading at most
+// defaultMaxWalkRows rows of each. Tables the device doesn't have are skipped.
+func discover(params *gosnmp.GoSNMP) discoveryResult {
+	result := discoveryResult{
+		Metrics: make(map[string]MetricConfig),
+		Values:  make(map[string]float64),
+	}
+
+	sensors, names, err := readEntitySensors(params)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	for index, sensor := range sensors {
+		if !sensor.hasValue || sensor.operStatus != 1 {
+			continue
+		}
+		kind := sensor.kind()
+		key := "entity_" + index
+		result.Metrics[key] = MetricConfig{
+			OID:      fmt.Sprintf("%s.1.%d.%s", entPhySensorTable, entPhySensorValue, index),
+			Name:     entitySensorName(names, index),
+			Unit:     kind.unit,
+			Category: kind.category,
+			Scale:    sensor.factor(),
+		}
+		result.Values[key] = transformValue(sensor.value, result.Metrics[key])
+	}
+
+	for _, table := range discoveryTables {
+		values, err := walkColumn(params, table.value, defaultMaxWalkRows)
+		if err != nil && !errors.Is(err, errWalkLimit) {
+			result.Errors = append(result.Errors, fmt.Sprintf("walk %s: %v", table.key, err))
+			continue
+		}
+		var labels []gosnmp.SnmpPDU
+		if table.label != "" {
+			labels, _ = walkColumn(params, table.label, defaultMaxWalkRows)
+		}
+
+		for _, row := range joinRows(table.value, values, table.label, labels) {
+			value := convertSNMPValue(row.variable.Value)
+			if value == nil {
+				continue
+			}
+			name := row.label
+			if name == "" {
+				name = table.name
+				if row.index != "0" {
+					name += " " + row.index
+				}
+			}
+			key := table.key
+			if row.index != "0" {
+				key += "_" + row.index
+			}
+			result.Metrics[key] = MetricConfig{
+				OID:      row.variable.Name,
+				Name:     name,
+				Unit:     table.unit,
+				Category: table.category,
+				Scale:    table.scale,
+			}
+			result.Values[key] = transformValue(*value, result.Metrics[key])
+		}
+	}
+
+	log.Printf("Discovered %d metrics on %s", len(result.Metrics), params.Target)
+	return result
+}
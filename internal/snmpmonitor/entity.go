@@ -0,0 +1,186 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Device modes
+const (
+	// ModeEntitySensors discovers metrics from the ENTITY-SENSOR-MIB instead of configured OIDs
+	ModeEntitySensors = "entity-sensors"
+)
+
+// ENTITY-SENSOR-MIB (RFC 3433) and ENTITY-MIB OIDs
+const (
+	entPhySensorTable = ".1.3.6.1.2.1.99.1.1.1"
+	entPhysicalName   = ".1.3.6.1.2.1.47.1.1.1.1.7"
+)
+
+// entPhySensorTable columns
+const (
+	entPhySensorType       = 1
+	entPhySensorScale      = 2
+	entPhySensorPrecision  = 3
+	entPhySensorValue      = 4
+	entPhySensorOperStatus = 5
+)
+
+// entitySensorTypes maps entPhySensorType values to a unit and category
+var entitySensorTypes = map[int]struct{ unit, category string }{
+	3:  {"V", "voltage"},      // voltsAC
+	4:  {"V", "voltage"},      // voltsDC
+	5:  {"A", "current"},      // amperes
+	6:  {"W", "power"},        // watts
+	7:  {"Hz", "frequency"},   // hertz
+	8:  {"°C", "temperature"}, // celsius
+	9:  {"%", "humidity"},     // percentRH
+	10: {"RPM", "fan"},        // rpm
+	11: {"CMM", "airflow"},    // cubic meters per minute
+	12: {"", "status"},        // truthvalue
+}
+
+// entitySensorScales maps entPhySensorScale values to a power of ten
+var entitySensorScales = map[int]int{
+	1: -24, 2: -21, 3: -18, 4: -15, 5: -12, 6: -9, 7: -6, 8: -3, 9: 0,
+	10: 3, 11: 6, 12: 9, 13: 12, 14: 18, 15: 15, 16: 21, 17: 24,
+}
+
+// validateMode checks the device's mode setting
+func (d *DeviceConfig) validateMode() error {
+	switch d.Mode {
+	case "", ModeEntitySensors:
+	case ModeInterfaces:
+		if _, err := d.Interfaces.compile(); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q: must be %q, %q or empty", d.Mode, ModeEntitySensors, ModeInterfaces)
+	}
+	if d.Interfaces != nil {
+		return fmt.Errorf("interfaces requires mode %q", ModeInterfaces)
+	}
+	return nil
+}
+
+// entitySensor collects the columns of one entPhySensorTable row
+type entitySensor struct {
+	sensorType int
+	scale      int
+	precision  int
+	value      float64
+	hasValue   bool
+	operStatus int
+}
+
+// pollEntitySensors walks entPhySensorTable and entPhysicalName and returns a metric for
+// every operational sensor, named after its physical entity and with the unit and
+// category of its sensor type. Metrics are keyed entity_<index>.
+func (p *Poller) pollEntitySensors(params *gosnmp.GoSNMP) (map[string]MetricValue, error) {
+	sensors, names, err := readEntitySensors(params)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]MetricValue)
+	for index, sensor := range sensors {
+		// operStatus 1 is ok; unavailable and nonoperational sensors have no meaningful value
+		if !sensor.hasValue || sensor.operStatus != 1 {
+			continue
+		}
+		kind := sensor.kind()
+		name := entitySensorName(names, index)
+
+		value := sensor.value * sensor.factor()
+		key := "entity_" + index
+
+		p.mu.Lock()
+		p.lastValues[key] = value
+		p.mu.Unlock()
+
+		metrics[key] = MetricValue{
+			Name:     name,
+			Value:    value,
+			Unit:     kind.unit,
+			Category: kind.category,
+		}
+	}
+	return metrics, nil
+}
+
+// readEntitySensors walks entPhySensorTable and entPhysicalName and returns the sensors and
+// entity names by index. Entity names are optional and left empty if the walk fails.
+func readEntitySensors(params *gosnmp.GoSNMP) (map[string]*entitySensor, map[string]string, error) {
+	rows, err := params.BulkWalkAll(entPhySensorTable)
+	if err != nil {
+		return nil, nil, fmt.Errorf("walk entPhySensorTable: %w", err)
+	}
+
+	sensors := make(map[string]*entitySensor)
+	for _, variable := range rows {
+		// <table>.1.<column>.<index>
+		column, index, ok := strings.Cut(strings.TrimPrefix(variable.Name, entPhySensorTable+".1."), ".")
+		if !ok {
+			continue
+		}
+		col, err := strconv.Atoi(column)
+		if err != nil {
+			continue
+		}
+		sensor, exists := sensors[index]
+		if !exists {
+			sensor = &entitySensor{}
+			sensors[index] = sensor
+		}
+		n := gosnmp.ToBigInt(variable.Value).Int64()
+		switch col {
+		case entPhySensorType:
+			sensor.sensorType = int(n)
+		case entPhySensorScale:
+			sensor.scale = int(n)
+		case entPhySensorPrecision:
+			sensor.precision = int(n)
+		case entPhySensorValue:
+			sensor.value, sensor.hasValue = float64(n), true
+		case entPhySensorOperStatus:
+			sensor.operStatus = int(n)
+		}
+	}
+
+	names := make(map[string]string)
+	if entities, err := params.BulkWalkAll(entPhysicalName); err == nil {
+		for _, variable := range entities {
+			if name, ok := variable.Value.([]byte); ok && len(name) > 0 {
+				names[strings.TrimPrefix(variable.Name, entPhysicalName+".")] = string(name)
+			}
+		}
+	}
+	return sensors, names, nil
+}
+
+// kind returns the unit and category of the sensor's type
+func (s *entitySensor) kind() struct{ unit, category string } {
+	kind, known := entitySensorTypes[s.sensorType]
+	if !known {
+		kind.category = "other"
+	}
+	return kind
+}
+
+// factor returns the multiplier that converts the sensor's raw value to its unit
+func (s *entitySensor) factor() float64 {
+	return math.Pow10(entitySensorScales[s.scale] - s.precision)
+}
+
+// entitySensorName returns the physical entity name of the sensor at index, or a generic name
+func entitySensorName(names map[string]string, index string) string {
+	if name := names[index]; name != "" {
+		return name
+	}
+	return "Sensor " + index
+}
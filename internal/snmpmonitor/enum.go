@@ -0,0 +1,24 @@
+package snmpmonitor
+
+import "math"
+
+// enumLabel returns the enum_map label for a raw polled value. Only whole numbers are looked up.
+func (m *MetricConfig) enumLabel(value float64) (string, bool) {
+	if len(m.EnumMap) == 0 || value != math.Trunc(value) {
+		return "", false
+	}
+	label, ok := m.EnumMap[int(value)]
+	return label, ok
+}
+
+// GetLastLabels returns the enum labels of the last polled values, by metric key
+func (p *Poller) GetLastLabels() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]string, len(p.lastLabels))
+	for k, v := range p.lastLabels {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"log"
+)
+
+// eventMode reports whether the metric is only forwarded when it crosses a threshold
+func (m *MetricConfig) eventMode() bool {
+	return m.AlertAbove != nil || m.AlertBelow != nil
+}
+
+// inAlert reports whether value is in the metric's alert range
+func (m *MetricConfig) inAlert(value float64) bool {
+	return (m.AlertAbove != nil && value > *m.AlertAbove) ||
+		(m.AlertBelow != nil && value < *m.AlertBelow)
+}
+
+// validateAlert checks the metric's alert thresholds
+func (m *MetricConfig) validateAlert() error {
+	if m.AlertAbove != nil && m.AlertBelow != nil && *m.AlertBelow >= *m.AlertAbove {
+		return fmt.Errorf("alert_below must be lower than alert_above")
+	}
+	return nil
+}
+
+// filterEvents removes event-mode metrics from metrics unless they crossed into or
+// out of their alert range since the previous poll. A metric that is in range on
+// the first poll is not forwarded until it first enters the alert range.
+func (p *Poller) filterEvents(metrics map[string]MetricValue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, metric := range p.device.Metrics {
+		value, ok := metrics[name]
+		if !ok || !metric.eventMode() {
+			continue
+		}
+
+		alert := metric.inAlert(value.Value)
+		if alert == p.alertState[name] {
+			delete(metrics, name)
+			continue
+		}
+		p.alertState[name] = alert
+
+		if alert {
+			log.Printf("Device %s: %s entered alert range at %v", p.device.Name, name, value.Value)
+		} else {
+			log.Printf("Device %s: %s returned to normal at %v", p.device.Name, name, value.Value)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package snmpmonitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// deviceFingerprint returns the fingerprint a device is registered under on the hub
+func deviceFingerprint(name, ip string) string {
+	base := fmt.Sprintf("snmp-device-%s-%s", name, ip)
+	sum := sha256.Sum256([]byte(base))
+	return hex.EncodeToString(sum[:24])
+}
+
+// devicesByIP groups the names of enabled devices by IP
+func (c *Config) devicesByIP() map[string][]string {
+	byIP := make(map[string][]string)
+	for _, device := range c.Devices {
+		if !device.Disabled {
+			byIP[device.IP] = append(byIP[device.IP], device.Name)
+		}
+	}
+	return byIP
+}
+
+// hubConflicts returns the enabled devices that would share a hub entry, mapping each
+// device name to the names of the others. The hub client keeps one connection per IP,
+// so devices polled on the same IP are reported as one system under the fingerprint of
+// whichever reported first, and overwrite each other's data.
+func (c *Config) hubConflicts() map[string][]string {
+	byIP := c.devicesByIP()
+
+	conflicts := make(map[string][]string)
+	for _, names := range byIP {
+		if len(names) < 2 {
+			continue
+		}
+		for _, name := range names {
+			for _, other := range names {
+				if other != name {
+					conflicts[name] = append(conflicts[name], other)
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// checkHubConflicts logs a warning for every group of devices that would share a hub
+// entry. With on_duplicate_fingerprint "error" the configuration is rejected instead.
+func (c *Config) checkHubConflicts() error {
+	policy := c.GetDefaults().OnDuplicateFingerprint
+	if policy != "" && policy != "warn" && policy != "error" {
+		return fmt.Errorf("invalid on_duplicate_fingerprint %q: must be \"warn\" or \"error\"", policy)
+	}
+
+	byIP := c.devicesByIP()
+	ips := make([]string, 0, len(byIP))
+	for ip, names := range byIP {
+		if len(names) > 1 {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+
+	for _, ip := range ips {
+		names := byIP[ip]
+		if policy == "error" {
+			return fmt.Errorf("devices %s share IP %s and would be reported to the hub as one system", strings.Join(names, ", "), ip)
+		}
+		log.Printf("WARNING: devices %s share IP %s and are reported to the hub as one system (fingerprint %s), overwriting each other's data",
+			strings.Join(names, ", "), ip, deviceFingerprint(names[0], ip))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package snmpmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// maxHistorySize caps the number of samples kept per metric
+const maxHistorySize = 10000
+
+// historySample is one recorded metric value
+type historySample struct {
+	time  time.Time
+	value float64
+}
+
+// metricHistory is a fixed-size ring buffer of a metric's most recent samples
+type metricHistory struct {
+	samples []historySample
+	next    int
+	full    bool
+}
+
+func newMetricHistory(size int) *metricHistory {
+	return &metricHistory{samples: make([]historySample, size)}
+}
+
+// add records a sample, overwriting the oldest one when the buffer is full
+func (h *metricHistory) add(sample historySample) {
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// valuesSince returns the values of the samples recorded at or after since, and the
+// number of gap markers among them, which are left out of the values
+func (h *metricHistory) valuesSince(since time.Time) ([]float64, int) {
+	count := h.next
+	if h.full {
+		count = len(h.samples)
+	}
+	values := make([]float64, 0, count)
+	gaps := 0
+	for i := range count {
+		sample := h.samples[i]
+		switch {
+		case sample.time.Before(since):
+		case math.IsNaN(sample.value):
+			gaps++
+		default:
+			values = append(values, sample.value)
+		}
+	}
+	return values, gaps
+}
+
+// recordHistory adds the polled metrics to their history when a history size is configured.
+// With history_gaps, every metric that has a history but no value in this cycle, because
+// the poll failed or the device didn't return it, gets a NaN gap marker instead, so a
+// missed cycle can be told apart from an unchanged value. A failed poll passes nil metrics.
+func (p *Poller) recordHistory(metrics map[string]MetricValue, now time.Time) {
+	if p.historySize <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, metric := range metrics {
+		history := p.history[key]
+		if history == nil {
+			history = newMetricHistory(p.historySize)
+			p.history[key] = history
+		}
+		history.add(historySample{time: now, value: metric.Value})
+	}
+	if !p.historyGaps {
+		return
+	}
+	for key, history := range p.history {
+		if _, ok := metrics[key]; !ok {
+			history.add(historySample{time: now, value: math.NaN()})
+		}
+	}
+}
+
+// MetricStats summarizes the recorded history of a metric
+type MetricStats struct {
+	Metric string  `json:"metric"`
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	P95    float64 `json:"p95"`
+	// Gaps is the number of missed poll cycles in the window, recorded with history_gaps
+	Gaps int `json:"gaps,omitempty"`
+}
+
+// GetMetricStats computes statistics over the samples of a metric recorded at or after since.
+// It returns false if the metric has no samples in that window.
+func (p *Poller) GetMetricStats(key string, since time.Time) (MetricStats, bool) {
+	p.mu.RLock()
+	history := p.history[key]
+	var values []float64
+	var gaps int
+	if history != nil {
+		values, gaps = history.valuesSince(since)
+	}
+	p.mu.RUnlock()
+
+	if len(values) == 0 {
+		return MetricStats{}, false
+	}
+
+	sort.Float64s(values)
+	stats := MetricStats{
+		Metric: key,
+		Count:  len(values),
+		Min:    values[0],
+		Max:    values[len(values)-1],
+		Gaps:   gaps,
+	}
+	for _, value := range values {
+		stats.Avg += value
+	}
+	stats.Avg /= float64(len(values))
+	// Nearest-rank percentile
+	stats.P95 = values[int(math.Ceil(0.95*float64(len(values))))-1]
+	return stats, true
+}
+
+// handleDeviceStats returns min, max, average and 95th percentile of a metric's recorded
+// history. The optional window parameter, a duration such as 1h, limits the samples used.
+func (ws *WebServer) handleDeviceStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	name, metric := query.Get("name"), query.Get("metric")
+	if name == "" || metric == "" {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("name and metric are required"), http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if window := query.Get("window"); window != "" {
+		duration, err := time.ParseDuration(window)
+		if err != nil || duration <= 0 {
+			ws.sendJSONError(w, "Invalid window", fmt.Errorf("window must be a positive duration such as 15m or 1h"), http.StatusBadRequest)
+			return
+		}
+		since = time.Now().Add(-duration)
+	}
+
+	if ws.agent.GetConfig().GetDefaults().HistorySize <= 0 {
+		ws.sendJSONError(w, "History disabled", fmt.Errorf("set defaults history_size to record metric history"), http.StatusNotFound)
+		return
+	}
+	poller, ok := ws.agent.GetPoller(name)
+	if !ok {
+		ws.sendJSONError(w, "Device not found", fmt.Errorf("no running poller for device %q", name), http.StatusNotFound)
+		return
+	}
+	stats, ok := poller.GetMetricStats(metric, since)
+	if !ok {
+		ws.sendJSONError(w, "No history", fmt.Errorf("no samples of metric %q in the window", metric), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -1,11 +1,13 @@
 package snmpmonitor
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
+	"net"
+	"net/http"
 	"net/url"
 	"path"
 	"strings"
@@ -27,6 +29,8 @@ type HubClient struct {
 	token  string
 	mu     sync.Mutex
 	conns  map[string]*deviceClient
+	// sharedWarned records the devices already warned about reporting on another device's connection
+	sharedWarned map[string]bool
 }
 
 type deviceClient struct {
@@ -42,6 +46,19 @@ type deviceClient struct {
 	hasTriedNoToken bool // Whether we've tried connecting without token
 	backoff         time.Duration
 	heartbeat       *time.Ticker
+	// unverifiedUpdates counts updates collected while the hub handshake was incomplete
+	unverifiedUpdates int
+	// closed is set when the owning hub client is replaced, stopping reconnects
+	closed bool
+	// collect, when set, replaces the metric-based data with synthetic data (used for the collector itself)
+	collect func() *system.CombinedData
+	// lastUpdate is when lastData was collected
+	lastUpdate time.Time
+	// lastPoll is when the device last answered a poll. It keeps advancing while lastData
+	// is held back, e.g. for event-mode metrics that only report on changes.
+	lastPoll time.Time
+	// sendFailures counts consecutive failed writes on the current connection
+	sendFailures int
 }
 
 // Helper functions for parsing URL and public key
@@ -54,6 +71,19 @@ func parseURL(urlStr string) *url.URL {
 	return u
 }
 
+// validateHubURL checks the syntax of the hub URL: it must use http or https and name a host.
+// The host is not resolved here, so a hub that isn't up yet doesn't stop the monitor;
+// verifyReachable does that when verify_at_startup is set.
+func validateHubURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid hub URL %s: scheme must be http or https", u)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("invalid hub URL %s: missing host", u)
+	}
+	return nil
+}
+
 func parsePublicKey(keyStr string) gossh.PublicKey {
 	if keyStr == "" {
 		return nil
@@ -71,6 +101,8 @@ func NewHubClient(config HubConfig) (*HubClient, error) {
 		config: &config,
 		token:  strings.TrimSpace(config.Token),
 		conns:  make(map[string]*deviceClient),
+
+		sharedWarned: make(map[string]bool),
 	}
 
 	// Parse the hub URL
@@ -78,6 +110,12 @@ func NewHubClient(config HubConfig) (*HubClient, error) {
 	if client.url == nil {
 		return nil, fmt.Errorf("invalid hub URL: %s", config.URL)
 	}
+	// An empty URL leaves the hub unconfigured until it is set in the web interface
+	if config.URL != "" {
+		if err := validateHubURL(client.url); err != nil {
+			return nil, err
+		}
+	}
 
 	// Parse the public key
 	client.pubKey = parsePublicKey(config.Key)
@@ -85,12 +123,43 @@ func NewHubClient(config HubConfig) (*HubClient, error) {
 	return client, nil
 }
 
+// verifyReachable checks that the hub's host resolves and that the hub answers an HTTP
+// request within the connect timeout. Any response counts, since only the WebSocket
+// endpoint requires authentication.
+func (c *HubClient) verifyReachable() error {
+	if _, err := net.LookupHost(c.url.Hostname()); err != nil {
+		return fmt.Errorf("hub host %s does not resolve: %w", c.url.Hostname(), err)
+	}
+	client := &http.Client{Timeout: c.config.GetConnectTimeout()}
+	resp, err := client.Get(c.url.String())
+	if err != nil {
+		return fmt.Errorf("hub is not reachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
 func (c *HubClient) NotifyDevice(deviceData DeviceData) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	key := deviceData.IP
 	dc, ok := c.conns[key]
+
+	// Don't register a device, or replace its last report, until it has a valid reading.
+	// Otherwise the hub briefly shows an empty system.
+	if !hasValidReading(deviceData.Metrics) {
+		if !ok {
+			log.Printf("Device %s has no valid reading yet, not reporting to hub", deviceData.Name)
+		}
+		return
+	}
+
+	if ok && dc.deviceName != deviceData.Name && !c.sharedWarned[deviceData.Name] {
+		c.sharedWarned[deviceData.Name] = true
+		log.Printf("WARNING: device %s reports on the hub connection of device %s (IP %s, fingerprint %s); both appear on the hub as one system",
+			deviceData.Name, dc.deviceName, deviceData.IP, dc.generateDeviceFingerprint())
+	}
 	if !ok {
 		dc = &deviceClient{
 			deviceIP:   deviceData.IP,
@@ -101,9 +170,15 @@ func (c *HubClient) NotifyDevice(deviceData DeviceData) {
 		go dc.connect(c)
 	}
 
-	// Update the device data
+	// Update the device data. Until the hub is verified the latest data is held
+	// and reported on the first request after the handshake completes.
 	dc.mu.Lock()
 	dc.lastData = deviceData
+	dc.lastUpdate = time.Now()
+	if !dc.hubVerified {
+		dc.unverifiedUpdates++
+		log.Printf("Hub not yet verified for device %s, holding update (%d collected before verification)", deviceData.IP, dc.unverifiedUpdates)
+	}
 	dc.mu.Unlock()
 
 	// Log the data for debugging
@@ -129,6 +204,98 @@ func (c *HubClient) NotifyDevice(deviceData DeviceData) {
 	log.Printf("JSON Data: %+v", jsonData)
 }
 
+// MarkPolled records that the device with the given IP answered a poll at the given time,
+// so the data held for it is reported as current even when the poll sent nothing new
+func (c *HubClient) MarkPolled(ip string, at time.Time) {
+	c.mu.Lock()
+	dc, ok := c.conns[ip]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	dc.mu.Lock()
+	dc.lastPoll = at
+	dc.mu.Unlock()
+}
+
+// ReportSelf registers the monitor itself as a device on the hub, reporting the data returned by collect
+func (c *HubClient) ReportSelf(name string, collect func() *system.CombinedData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := "collector:" + name
+	if _, ok := c.conns[key]; ok {
+		return
+	}
+	dc := &deviceClient{
+		deviceIP:   name,
+		deviceName: name,
+		cfg:        c.config,
+		collect:    collect,
+	}
+	c.conns[key] = dc
+	go dc.connect(c)
+}
+
+// RemoveDevice closes the hub connection of the device with the given IP so it stops
+// reporting to the hub. A later NotifyDevice for the IP opens a new connection.
+func (c *HubClient) RemoveDevice(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if dc, ok := c.conns[ip]; ok {
+		dc.close()
+		delete(c.conns, ip)
+	}
+}
+
+// Close disconnects all device connections of this client and stops them from reconnecting.
+// It is used when the hub client is replaced after a configuration change.
+func (c *HubClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, dc := range c.conns {
+		dc.close()
+		delete(c.conns, key)
+	}
+}
+
+// Connected reports whether any device currently has an open connection to the hub
+func (c *HubClient) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, dc := range c.conns {
+		dc.mu.Lock()
+		connected := dc.conn != nil
+		dc.mu.Unlock()
+		if connected {
+			return true
+		}
+	}
+	return false
+}
+
+// close marks the device client as closed and shuts down its connection
+func (dc *deviceClient) close() {
+	dc.mu.Lock()
+	dc.closed = true
+	conn := dc.conn
+	dc.mu.Unlock()
+
+	if conn != nil {
+		_ = conn.WriteClose(1000, nil)
+	}
+}
+
+// isClosed reports whether the device client has been closed
+func (dc *deviceClient) isClosed() bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.closed
+}
+
 func (dc *deviceClient) getOptions(hubClient *HubClient) *gws.ClientOption {
 	if hubClient.url == nil {
 		return &gws.ClientOption{}
@@ -156,13 +323,23 @@ func (dc *deviceClient) getOptions(hubClient *HubClient) *gws.ClientOption {
 		headers["X-Token"] = []string{hubClient.token}
 	}
 
+	// Bound both the TCP dial and the WebSocket handshake so a blackholed hub
+	// fails the attempt and is retried with backoff instead of hanging
+	timeout := dc.cfg.GetConnectTimeout()
 	return &gws.ClientOption{
-		Addr:          u.String(),
-		RequestHeader: headers,
+		Addr:             u.String(),
+		RequestHeader:    headers,
+		HandshakeTimeout: timeout,
+		NewDialer: func() (gws.Dialer, error) {
+			return &net.Dialer{Timeout: timeout}, nil
+		},
 	}
 }
 
 func (dc *deviceClient) connect(hubClient *HubClient) {
+	if dc.isClosed() {
+		return
+	}
 	opt := dc.getOptions(hubClient)
 	if opt.Addr == "" {
 		log.Printf("WebSocket not configured for device %s", dc.deviceIP)
@@ -221,7 +398,15 @@ func (dc *deviceClient) connect(hubClient *HubClient) {
 		return
 	}
 
+	dc.mu.Lock()
 	dc.conn = conn
+	dc.sendFailures = 0
+	closed := dc.closed
+	dc.mu.Unlock()
+	if closed {
+		_ = conn.WriteClose(1000, nil)
+		return
+	}
 	log.Printf("Device %s connected to hub", dc.deviceIP)
 	go conn.ReadLoop()
 }
@@ -244,7 +429,7 @@ func (dc *deviceClient) OnOpen(conn *gws.Conn) {
 				return
 			}
 			c.SetDeadline(time.Now().Add(70 * time.Second))
-			_ = c.WritePing(nil)
+			dc.recordSend(c, c.WritePing(nil))
 		}
 	}(conn, dc.heartbeat, dc.deviceIP)
 
@@ -257,7 +442,9 @@ func (dc *deviceClient) OnOpen(conn *gws.Conn) {
 
 func (dc *deviceClient) OnClose(conn *gws.Conn, err error) {
 	log.Printf("WebSocket connection closed for device %s: %v", dc.deviceIP, err)
+	dc.mu.Lock()
 	dc.hubVerified = false
+	dc.mu.Unlock()
 	if dc.heartbeat != nil {
 		dc.heartbeat.Stop()
 		dc.heartbeat = nil
@@ -266,8 +453,15 @@ func (dc *deviceClient) OnClose(conn *gws.Conn, err error) {
 	// For reconnections, try without token first since we were successfully connected
 	dc.mu.Lock()
 	dc.needsToken = false // Try without token first for reconnection
+	dc.conn = nil
+	closed := dc.closed
 	dc.mu.Unlock()
 
+	if closed {
+		log.Printf("Device %s hub client closed, not reconnecting", dc.deviceIP)
+		return
+	}
+
 	// Reconnect with current backoff (will be increased on connect failure)
 	if dc.backoff == 0 {
 		dc.backoff = 5 * time.Second
@@ -332,10 +526,23 @@ func (dc *deviceClient) handleFingerprintRequest(conn *gws.Conn, data cbor.RawMe
 		return
 	}
 
-	// For now, skip signature verification and mark as verified
-	// TODO: Implement proper signature verification
+	if dc.cfg.SkipSignatureVerification {
+		log.Printf("Signature verification disabled, trusting hub for device %s", dc.deviceIP)
+	} else if err := dc.verifySignature(fr.Signature); err != nil {
+		log.Printf("Hub verification failed for device %s: %v", dc.deviceIP, err)
+		_ = conn.WriteClose(1000, nil)
+		return
+	}
+
+	dc.mu.Lock()
 	dc.hubVerified = true
+	held := dc.unverifiedUpdates
+	dc.unverifiedUpdates = 0
+	dc.mu.Unlock()
 	log.Printf("Hub verified for device %s", dc.deviceIP)
+	if held > 0 {
+		log.Printf("Device %s collected %d updates before hub verification, latest state will be sent", dc.deviceIP, held)
+	}
 
 	// Generate fingerprint for this specific device
 	fingerprint := dc.generateDeviceFingerprint()
@@ -357,11 +564,43 @@ func (dc *deviceClient) handleFingerprintRequest(conn *gws.Conn, data cbor.RawMe
 	}
 }
 
+// verifySignature verifies the hub's signature of the token using the configured hub public key
+func (dc *deviceClient) verifySignature(signature []byte) error {
+	pubKey := parsePublicKey(dc.cfg.Key)
+	if pubKey == nil {
+		return errors.New("no valid hub public key configured - check KEY value")
+	}
+	if len(signature) == 0 {
+		return errors.New("hub sent no signature")
+	}
+	sig := gossh.Signature{
+		Format: pubKey.Type(),
+		Blob:   signature,
+	}
+	if err := pubKey.Verify([]byte(strings.TrimSpace(dc.cfg.Token)), &sig); err != nil {
+		return errors.New("invalid signature - check KEY value")
+	}
+	return nil
+}
+
 func (dc *deviceClient) handleGetDataRequest(conn *gws.Conn) {
 	log.Printf("Hub requested data for device %s", dc.deviceIP)
 
+	dc.mu.Lock()
+	verified := dc.hubVerified
+	dc.mu.Unlock()
+	if !verified {
+		log.Printf("Ignoring data request for device %s: hub not verified", dc.deviceIP)
+		return
+	}
+
 	// Build the combined data for this specific device
-	combinedData := dc.buildCombinedData()
+	var combinedData *system.CombinedData
+	if dc.collect != nil {
+		combinedData = dc.collect()
+	} else {
+		combinedData = dc.buildCombinedData()
+	}
 
 	// Send the data
 	if err := dc.sendMessage(conn, combinedData); err != nil {
@@ -373,28 +612,31 @@ func (dc *deviceClient) handleGetDataRequest(conn *gws.Conn) {
 
 func (dc *deviceClient) generateDeviceFingerprint() string {
 	// Generate a unique fingerprint for this specific SNMP device
-	base := fmt.Sprintf("snmp-device-%s-%s", dc.deviceName, dc.deviceIP)
-	sum := sha256.Sum256([]byte(base))
-	return hex.EncodeToString(sum[:24])
+	return deviceFingerprint(dc.deviceName, dc.deviceIP)
 }
 
-func (dc *deviceClient) buildCombinedData() *system.CombinedData {
-	dc.mu.Lock()
-	defer dc.mu.Unlock()
-
-	// Build stats from the device's metrics
+// statsFromMetrics sorts device metrics into the hub's sensor categories.
+// Metrics in other categories are left out.
+func statsFromMetrics(metrics map[string]MetricValue) system.Stats {
 	stats := system.Stats{
-		Temperatures: make(map[string]float64),
-		Humidity:     make(map[string]float64),
-		CO2:          make(map[string]float64),
-		Pressure:     make(map[string]float64),
-		PM25:         make(map[string]float64),
-		PM10:         make(map[string]float64),
-		VOC:          make(map[string]float64),
+		Temperatures:  make(map[string]float64),
+		Humidity:      make(map[string]float64),
+		CO2:           make(map[string]float64),
+		Pressure:      make(map[string]float64),
+		PM25:          make(map[string]float64),
+		PM10:          make(map[string]float64),
+		VOC:           make(map[string]float64),
+		Voltage:       make(map[string]float64),
+		Current:       make(map[string]float64),
+		Power:         make(map[string]float64),
+		Fan:           make(map[string]float64),
+		BatteryCharge: make(map[string]float64),
+		Runtime:       make(map[string]float64),
+		UPSLoad:       make(map[string]float64),
 	}
 
 	// Convert device metrics to the appropriate stat categories
-	for _, metric := range dc.lastData.Metrics {
+	for _, metric := range metrics {
 		switch strings.ToLower(metric.Category) {
 		case "temperature", "temp", "t":
 			stats.Temperatures[metric.Name] = metric.Value
@@ -410,8 +652,42 @@ func (dc *deviceClient) buildCombinedData() *system.CombinedData {
 			stats.PM10[metric.Name] = metric.Value
 		case "voc":
 			stats.VOC[metric.Name] = metric.Value
+		case "voltage", "volt", "v":
+			stats.Voltage[metric.Name] = metric.Value
+		case "current", "amperage", "a":
+			stats.Current[metric.Name] = metric.Value
+		case "power", "watts", "w":
+			stats.Power[metric.Name] = metric.Value
+		case "fan", "rpm":
+			stats.Fan[metric.Name] = metric.Value
+		case "battery", "charge":
+			stats.BatteryCharge[metric.Name] = metric.Value
+		case "runtime":
+			stats.Runtime[metric.Name] = metric.Value
+		case "load":
+			stats.UPSLoad[metric.Name] = metric.Value
+		}
+	}
+	return stats
+}
+
+// hasValidReading reports whether at least one of the metrics has a finite value, whatever
+// its category. Zero is a valid reading, e.g. an idle current or a stopped fan; values
+// that could not be converted were already dropped when polling.
+func hasValidReading(metrics map[string]MetricValue) bool {
+	for _, metric := range metrics {
+		if !math.IsNaN(metric.Value) && !math.IsInf(metric.Value, 0) {
+			return true
 		}
 	}
+	return false
+}
+
+func (dc *deviceClient) buildCombinedData() *system.CombinedData {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	stats := statsFromMetrics(dc.lastData.Metrics)
 
 	// Build info for this device
 	// Use device name if available, otherwise use IP address
@@ -424,89 +700,95 @@ func (dc *deviceClient) buildCombinedData() *system.CombinedData {
 		Hostname:     systemName,
 		AgentType:    "snmp",
 		AgentVersion: beszel.Version,
+		ExtraInfo:    map[string]string{"collector_id": dc.cfg.GetCollectorID()},
 	}
 
-	// Add dashboard summaries for all sensor types
-	if len(stats.Temperatures) > 0 {
-		var maxTemp float64
-		for _, temp := range stats.Temperatures {
-			if temp > maxTemp {
-				maxTemp = temp
-			}
+	// Report the source OID of each metric when the poller includes it, and the enum label of its value
+	for _, metric := range dc.lastData.Metrics {
+		if metric.OID != "" {
+			info.ExtraInfo["oid:"+metric.Name] = metric.OID
 		}
-		info.DashboardTemp = maxTemp
-	}
-
-	// Add humidity summary
-	if len(stats.Humidity) > 0 {
-		var maxHumidity float64
-		for _, humidity := range stats.Humidity {
-			if humidity > maxHumidity {
-				maxHumidity = humidity
-			}
+		if metric.Label != "" {
+			info.ExtraInfo["label:"+metric.Name] = metric.Label
 		}
-		info.DashboardHumidity = maxHumidity
 	}
 
-	// Add CO2 summary
-	if len(stats.CO2) > 0 {
-		var maxCO2 float64
-		for _, co2 := range stats.CO2 {
-			if co2 > maxCO2 {
-				maxCO2 = co2
-			}
+	// Add dashboard summaries for all sensor types. Summaries are only set for categories
+	// that have readings, and each one set is flagged in DashboardPresent: a summary of
+	// zero is left out on the wire just like a missing one, so only the flag tells them apart.
+	summaries := []struct {
+		values  map[string]float64
+		summary *float64
+		flag    uint64
+		// lowest reports the smallest reading instead of the largest
+		lowest bool
+	}{
+		{stats.Temperatures, &info.DashboardTemp, system.DashboardHasTemp, false},
+		{stats.Humidity, &info.DashboardHumidity, system.DashboardHasHumidity, false},
+		{stats.CO2, &info.DashboardCO2, system.DashboardHasCO2, false},
+		{stats.Pressure, &info.DashboardPressure, system.DashboardHasPressure, false},
+		{stats.PM25, &info.DashboardPM25, system.DashboardHasPM25, false},
+		{stats.PM10, &info.DashboardPM10, system.DashboardHasPM10, false},
+		{stats.VOC, &info.DashboardVOC, system.DashboardHasVOC, false},
+		{stats.Voltage, &info.DashboardVoltage, system.DashboardHasVoltage, false},
+		{stats.Current, &info.DashboardCurrent, system.DashboardHasCurrent, false},
+		{stats.Power, &info.DashboardPower, system.DashboardHasPower, false},
+		{stats.Fan, &info.DashboardFan, system.DashboardHasFan, false},
+		// For UPSes the weakest battery is the one that matters
+		{stats.BatteryCharge, &info.DashboardBattery, system.DashboardHasBattery, true},
+		{stats.Runtime, &info.DashboardRuntime, system.DashboardHasRuntime, true},
+		{stats.UPSLoad, &info.DashboardUPSLoad, system.DashboardHasUPSLoad, false},
+	}
+	for _, s := range summaries {
+		value, ok := maxValue(s.values)
+		if s.lowest {
+			value, ok = minValue(s.values)
 		}
-		info.DashboardCO2 = maxCO2
-	}
-
-	// Add pressure summary
-	if len(stats.Pressure) > 0 {
-		var maxPressure float64
-		for _, pressure := range stats.Pressure {
-			if pressure > maxPressure {
-				maxPressure = pressure
-			}
+		if ok {
+			*s.summary = value
+			info.DashboardPresent |= s.flag
 		}
-		info.DashboardPressure = maxPressure
 	}
 
-	// Add PM2.5 summary
-	if len(stats.PM25) > 0 {
-		var maxPM25 float64
-		for _, pm25 := range stats.PM25 {
-			if pm25 > maxPM25 {
-				maxPM25 = pm25
-			}
-		}
-		info.DashboardPM25 = maxPM25
+	// The data is as current as the last poll that confirmed it
+	timestamp := dc.lastUpdate
+	if dc.lastPoll.After(timestamp) {
+		timestamp = dc.lastPoll
 	}
 
-	// Add PM10 summary
-	if len(stats.PM10) > 0 {
-		var maxPM10 float64
-		for _, pm10 := range stats.PM10 {
-			if pm10 > maxPM10 {
-				maxPM10 = pm10
-			}
-		}
-		info.DashboardPM10 = maxPM10
+	return &system.CombinedData{
+		Stats:     stats,
+		Info:      info,
+		Timestamp: timestamp.UnixMilli(),
 	}
+}
 
-	// Add VOC summary
-	if len(stats.VOC) > 0 {
-		var maxVOC float64
-		for _, voc := range stats.VOC {
-			if voc > maxVOC {
-				maxVOC = voc
-			}
+// maxValue returns the largest value in the map and whether the map had any values.
+// The result is seeded from the first reading rather than zero so that zero and
+// negative readings are reported correctly.
+func maxValue(values map[string]float64) (float64, bool) {
+	var max float64
+	found := false
+	for _, v := range values {
+		if !found || v > max {
+			max = v
+			found = true
 		}
-		info.DashboardVOC = maxVOC
 	}
+	return max, found
+}
 
-	return &system.CombinedData{
-		Stats: stats,
-		Info:  info,
+// minValue returns the smallest value in the map and whether the map had any values
+func minValue(values map[string]float64) (float64, bool) {
+	var min float64
+	found := false
+	for _, v := range values {
+		if !found || v < min {
+			min = v
+			found = true
+		}
 	}
+	return min, found
 }
 
 func (dc *deviceClient) sendMessage(conn *gws.Conn, data interface{}) error {
@@ -514,7 +796,33 @@ func (dc *deviceClient) sendMessage(conn *gws.Conn, data interface{}) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
-	return conn.WriteMessage(gws.OpcodeBinary, bytes)
+	err = conn.WriteMessage(gws.OpcodeBinary, bytes)
+	dc.recordSend(conn, err)
+	return err
+}
+
+// recordSend tracks consecutive write failures on conn. Once max_send_failures is reached the
+// underlying connection is closed, so a half-broken connection is torn down and reconnected
+// by OnClose instead of waiting for the transport to notice.
+func (dc *deviceClient) recordSend(conn *gws.Conn, err error) {
+	dc.mu.Lock()
+	if err == nil {
+		dc.sendFailures = 0
+		dc.mu.Unlock()
+		return
+	}
+	dc.sendFailures++
+	failures := dc.sendFailures
+	reset := failures >= dc.cfg.GetMaxSendFailures() && dc.conn == conn
+	if reset {
+		dc.sendFailures = 0
+	}
+	dc.mu.Unlock()
+
+	if reset {
+		log.Printf("%d consecutive sends to hub failed for device %s, resetting connection: %v", failures, dc.deviceIP, err)
+		conn.NetConn().Close()
+	}
 }
 
 // Legacy method for backward compatibility
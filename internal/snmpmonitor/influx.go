@@ -0,0 +1,244 @@
+package snmpmonitor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxInfluxBuffer caps the number of lines held while InfluxDB can't be reached
+const maxInfluxBuffer = 10000
+
+// InfluxConfig defines an InfluxDB v2 server that metrics are written to besides the hub
+type InfluxConfig struct {
+	URL    string `json:"url"`
+	Org    string `json:"org"`
+	Bucket string `json:"bucket"`
+	Token  string `json:"token"`
+	// Measurement is the measurement name of every point (default "snmp")
+	Measurement string `json:"measurement,omitempty"`
+	// FlushInterval is how often buffered points are written, in seconds
+	FlushInterval int `json:"flush_interval_sec,omitempty"`
+}
+
+// enabled reports whether InfluxDB export is configured
+func (c *InfluxConfig) enabled() bool {
+	return c != nil && c.URL != ""
+}
+
+// validate checks the InfluxDB settings
+func (c *InfluxConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid influx url %q: must be an http or https URL", c.URL)
+	}
+	if c.Org == "" || c.Bucket == "" {
+		return fmt.Errorf("influx org and bucket are required")
+	}
+	return nil
+}
+
+// GetMeasurement returns the measurement name of the written points
+func (c *InfluxConfig) GetMeasurement() string {
+	if c.Measurement == "" {
+		return "snmp"
+	}
+	return c.Measurement
+}
+
+// GetFlushInterval returns how often buffered points are written
+func (c *InfluxConfig) GetFlushInterval() time.Duration {
+	if c.FlushInterval <= 0 {
+		return 10 * time.Second // default 10 seconds
+	}
+	return time.Duration(c.FlushInterval) * time.Second
+}
+
+// metricSink is an output that receives every batch of metrics a poller sends, besides the hub
+type metricSink interface {
+	Write(data DeviceData, at time.Time)
+}
+
+// InfluxSink buffers metrics as InfluxDB line protocol and writes them on a flush interval.
+// Lines that fail to write are kept and retried with the next flush.
+type InfluxSink struct {
+	config InfluxConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	lines []string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newInfluxSink starts a sink for the configuration, or returns nil if export is not configured
+func newInfluxSink(config *InfluxConfig) *InfluxSink {
+	if !config.enabled() {
+		return nil
+	}
+	s := &InfluxSink{
+		config: *config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write adds a point for every metric of the device to the buffer
+func (s *InfluxSink) Write(data DeviceData, at time.Time) {
+	keys := make([]string, 0, len(data.Metrics))
+	for key := range data.Metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// NaN and infinities can't be written and would fail the whole batch
+		if value := data.Metrics[key].Value; math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		lines = append(lines, influxLine(s.config.GetMeasurement(), data, key, data.Metrics[key], at))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, lines...)
+	if dropped := len(s.lines) - maxInfluxBuffer; dropped > 0 {
+		log.Printf("InfluxDB buffer full, dropping %d oldest points", dropped)
+		s.lines = s.lines[dropped:]
+	}
+}
+
+// run flushes the buffer every flush interval until the sink is closed
+func (s *InfluxSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.GetFlushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush writes the buffered lines, putting them back in front of newer ones if the write fails
+func (s *InfluxSink) flush() {
+	s.mu.Lock()
+	lines := s.lines
+	s.lines = nil
+	s.mu.Unlock()
+	if len(lines) == 0 {
+		return
+	}
+
+	if err := s.write(lines); err != nil {
+		log.Printf("Failed to write %d points to InfluxDB: %v", len(lines), err)
+		s.mu.Lock()
+		s.lines = append(lines, s.lines...)
+		if dropped := len(s.lines) - maxInfluxBuffer; dropped > 0 {
+			s.lines = s.lines[dropped:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+// write sends lines to the InfluxDB v2 write API
+func (s *InfluxSink) write(lines []string) error {
+	query := url.Values{}
+	query.Set("org", s.config.Org)
+	query.Set("bucket", s.config.Bucket)
+	query.Set("precision", "ms")
+	endpoint := strings.TrimRight(s.config.URL, "/") + "/api/v2/write?" + query.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.config.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Close writes the remaining buffered points and stops the sink
+func (s *InfluxSink) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+// influxLine formats a metric as a line protocol point tagged with its device, key, name,
+// category, unit and sensor group
+func influxLine(measurement string, data DeviceData, key string, metric MetricValue, at time.Time) string {
+	var b strings.Builder
+	b.WriteString(influxEscape(measurement, ", "))
+	for _, tag := range [][2]string{
+		{"category", metric.Category},
+		{"device", data.Name},
+		{"group", metric.Group},
+		{"ip", data.IP},
+		{"metric", key},
+		{"name", metric.Name},
+		{"unit", metric.Unit},
+	} {
+		// Empty tag values are not allowed in line protocol
+		if tag[1] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(tag[0])
+		b.WriteByte('=')
+		b.WriteString(influxEscape(tag[1], ",= "))
+	}
+	b.WriteString(" value=")
+	b.WriteString(strconv.FormatFloat(metric.Value, 'g', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(at.UnixMilli(), 10))
+	return b.String()
+}
+
+// influxEscape backslash-escapes the special characters of a line protocol element
+func influxEscape(s, special string) string {
+	if !strings.ContainsAny(s, special+"\\") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\\' || strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
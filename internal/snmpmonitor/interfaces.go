@@ -0,0 +1,164 @@
+package snmpmonitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// ModeInterfaces reports the bandwidth of every interface in the IF-MIB instead of configured OIDs
+const ModeInterfaces = "interfaces"
+
+// defaultMaxInterfaces caps the interfaces read in interfaces mode when max_interfaces is not set
+const defaultMaxInterfaces = 512
+
+// IF-MIB columns. The 64-bit ifXTable counters are used when the device has them.
+const (
+	ifDescr       = ".1.3.6.1.2.1.2.2.1.2"
+	ifAdminStatus = ".1.3.6.1.2.1.2.2.1.7"
+	ifInOctets    = ".1.3.6.1.2.1.2.2.1.10"
+	ifOutOctets   = ".1.3.6.1.2.1.2.2.1.16"
+	ifXName       = ".1.3.6.1.2.1.31.1.1.1.1"
+	ifHCIn        = ".1.3.6.1.2.1.31.1.1.1.6"
+	ifHCOut       = ".1.3.6.1.2.1.31.1.1.1.10"
+)
+
+// InterfacesConfig selects the interfaces reported in interfaces mode
+type InterfacesConfig struct {
+	// Include is a regular expression matched against interface names; empty includes all
+	Include string `json:"include,omitempty"`
+	// IncludeAdminDown also reports interfaces that are administratively down
+	IncludeAdminDown bool `json:"include_admin_down,omitempty"`
+	// MaxInterfaces caps the number of interfaces read (default 512)
+	MaxInterfaces int `json:"max_interfaces,omitempty"`
+}
+
+// GetMaxInterfaces returns the maximum number of interfaces read from the device
+func (c *InterfacesConfig) GetMaxInterfaces() int {
+	if c == nil || c.MaxInterfaces <= 0 {
+		return defaultMaxInterfaces
+	}
+	return c.MaxInterfaces
+}
+
+// compile validates the interface settings and returns the include pattern, or nil if none is set
+func (c *InterfacesConfig) compile() (*regexp.Regexp, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.MaxInterfaces < 0 {
+		return nil, fmt.Errorf("max_interfaces must not be negative")
+	}
+	if c.Include == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(c.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interfaces include pattern: %w", err)
+	}
+	return re, nil
+}
+
+// pollInterfaces walks the IF-MIB and returns the inbound and outbound bandwidth of every
+// selected interface in bits per second, keyed if_<index>_in and if_<index>_out and named
+// after the interface. Octet counters are turned into rates like counter metrics, so
+// nothing is reported for an interface until its second poll.
+func (p *Poller) pollInterfaces(params *gosnmp.GoSNMP) (map[string]MetricValue, error) {
+	maxRows := p.device.Interfaces.GetMaxInterfaces()
+	read := func(column string) ([]gosnmp.SnmpPDU, error) {
+		rows, err := walkColumn(params, column, maxRows)
+		if errors.Is(err, errWalkLimit) {
+			log.Printf("Interface walk on device %s stopped at max_interfaces (%d)", p.device.Name, maxRows)
+			err = nil
+		}
+		return rows, err
+	}
+
+	// Prefer the 64-bit counters and ifName, falling back to the ifTable on older devices
+	inColumn, outColumn, nameColumn := ifHCIn, ifHCOut, ifXName
+	in, err := read(inColumn)
+	if err != nil {
+		return nil, err
+	}
+	if len(in) == 0 {
+		inColumn, outColumn, nameColumn = ifInOctets, ifOutOctets, ifDescr
+		if in, err = read(inColumn); err != nil {
+			return nil, err
+		}
+	}
+	out, err := read(outColumn)
+	if err != nil {
+		return nil, err
+	}
+	names, err := read(nameColumn)
+	if err != nil {
+		return nil, err
+	}
+	adminStatus, err := read(ifAdminStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	adminDown := make(map[string]bool)
+	for _, row := range joinRows(ifAdminStatus, adminStatus, "", nil) {
+		if value := convertSNMPValue(row.variable.Value); value != nil && *value != 1 {
+			adminDown[row.index] = true
+		}
+	}
+	outByIndex := make(map[string]gosnmp.SnmpPDU, len(out))
+	for _, row := range joinRows(outColumn, out, "", nil) {
+		outByIndex[row.index] = row.variable
+	}
+
+	counter := MetricConfig{Kind: KindCounter}
+	now := time.Now()
+	metrics := make(map[string]MetricValue)
+	for _, row := range joinRows(inColumn, in, nameColumn, names) {
+		name := row.label
+		if name == "" {
+			name = "Interface " + row.index
+		}
+		if adminDown[row.index] && (p.device.Interfaces == nil || !p.device.Interfaces.IncludeAdminDown) {
+			continue
+		}
+		if p.interfaceFilter != nil && !p.interfaceFilter.MatchString(name) {
+			continue
+		}
+
+		directions := []struct {
+			suffix, label string
+			variable      gosnmp.SnmpPDU
+			ok            bool
+		}{
+			{"in", "In", row.variable, true},
+			{"out", "Out", outByIndex[row.index], outByIndex[row.index].Name != ""},
+		}
+		for _, direction := range directions {
+			if !direction.ok {
+				continue
+			}
+			key := "if_" + row.index + "_" + direction.suffix
+			rate := p.counterRate(key, counter, direction.variable, now)
+			if rate == nil {
+				continue
+			}
+			value := *rate * 8
+
+			p.mu.Lock()
+			p.lastValues[key] = value
+			p.mu.Unlock()
+
+			metrics[key] = MetricValue{
+				Name:     name + " " + direction.label,
+				Value:    value,
+				Unit:     "bps",
+				Category: "bandwidth",
+			}
+		}
+	}
+	return metrics, nil
+}
@@ -0,0 +1,99 @@
+package snmpmonitor
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// limiterWarnInterval is the minimum time between warnings about a saturated poll limit
+const limiterWarnInterval = time.Minute
+
+// pollLimiter caps how many devices are polled at the same time across all pollers
+type pollLimiter struct {
+	slots    chan struct{}
+	waiting  atomic.Int64
+	total    atomic.Uint64
+	delayed  atomic.Uint64
+	lastWarn atomic.Int64
+}
+
+// PollStats describes the load on the poll concurrency limit
+type PollStats struct {
+	// MaxConcurrent is the configured limit, 0 when polls are not limited
+	MaxConcurrent int `json:"max_concurrent"`
+	Active        int `json:"active"`
+	// QueueDepth is the number of polls currently waiting for a free slot
+	QueueDepth int    `json:"queue_depth"`
+	PollsTotal uint64 `json:"polls_total"`
+	// PollsDelayed counts polls that had to wait for a free slot
+	PollsDelayed uint64 `json:"polls_delayed"`
+}
+
+// newPollLimiter returns a limiter allowing max concurrent polls, or nil if max is not positive
+func newPollLimiter(max int) *pollLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &pollLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits for a free poll slot and returns a function that releases it.
+// It returns false without a slot if stop or done is closed while waiting.
+// A nil limiter never blocks.
+func (l *pollLimiter) acquire(stop, done <-chan struct{}) (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	l.total.Add(1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, true
+	default:
+	}
+
+	l.delayed.Add(1)
+	if depth := l.waiting.Add(1); depth >= int64(cap(l.slots)) {
+		l.warn(depth)
+	}
+	defer l.waiting.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, true
+	case <-stop:
+		return nil, false
+	case <-done:
+		return nil, false
+	}
+}
+
+// release frees a poll slot
+func (l *pollLimiter) release() {
+	<-l.slots
+}
+
+// warn logs that polls are queuing, at most once per limiterWarnInterval
+func (l *pollLimiter) warn(depth int64) {
+	now := time.Now().Unix()
+	last := l.lastWarn.Load()
+	if now-last < int64(limiterWarnInterval.Seconds()) || !l.lastWarn.CompareAndSwap(last, now) {
+		return
+	}
+	log.Printf("Poll concurrency limit of %d reached, %d polls waiting; consider raising max_concurrent_polls or poll intervals", cap(l.slots), depth)
+}
+
+// stats returns the current load on the limiter
+func (l *pollLimiter) stats() PollStats {
+	if l == nil {
+		return PollStats{}
+	}
+	return PollStats{
+		MaxConcurrent: cap(l.slots),
+		Active:        len(l.slots),
+		QueueDepth:    int(l.waiting.Load()),
+		PollsTotal:    l.total.Load(),
+		PollsDelayed:  l.delayed.Load(),
+	}
+}
@@ -0,0 +1,359 @@
+package snmpmonitor
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// mqttQueueSize is the number of messages held while the broker is slow or unreachable
+	mqttQueueSize = 1000
+	// mqttKeepAlive is the keep-alive interval announced to the broker; a ping is sent at half of it
+	mqttKeepAlive = 60 * time.Second
+	// mqttMaxBackoff caps the delay between reconnect attempts
+	mqttMaxBackoff = time.Minute
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPingReq    = 0xC0
+	mqttDisconnect = 0xE0
+)
+
+// MQTTConfig defines an MQTT broker that every polled metric is published to
+type MQTTConfig struct {
+	// Broker is the broker URL: tcp://host:1883, or ssl:// or mqtts:// for TLS
+	Broker string `json:"broker"`
+	// TopicPrefix is prepended to every topic (default "beszel-snmp")
+	TopicPrefix string `json:"topic_prefix,omitempty"`
+	// QoS is 0 (default) or 1
+	QoS      int    `json:"qos,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Retain asks the broker to keep the last message of each topic for new subscribers
+	Retain bool `json:"retain,omitempty"`
+}
+
+// enabled reports whether MQTT publishing is configured
+func (c *MQTTConfig) enabled() bool {
+	return c != nil && c.Broker != ""
+}
+
+// validate checks the MQTT settings
+func (c *MQTTConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	u, err := url.Parse(c.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid mqtt broker %q: must be a URL such as tcp://host:1883", c.Broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return fmt.Errorf("invalid mqtt broker %q: scheme must be tcp, mqtt, ssl, tls or mqtts", c.Broker)
+	}
+	if c.QoS != 0 && c.QoS != 1 {
+		return fmt.Errorf("mqtt qos must be 0 or 1")
+	}
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("mqtt password requires a username")
+	}
+	return nil
+}
+
+// GetTopicPrefix returns the prefix of every published topic
+func (c *MQTTConfig) GetTopicPrefix() string {
+	if prefix := strings.Trim(c.TopicPrefix, "/"); prefix != "" {
+		return prefix
+	}
+	return "beszel-snmp"
+}
+
+// GetClientID returns the client identifier sent to the broker
+func (c *MQTTConfig) GetClientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+	return "beszel-snmp-" + hostname
+}
+
+// mqttMessage is one metric waiting to be published
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// MQTTSink publishes every metric as JSON to <prefix>/<device>/<metric>. Messages are
+// queued so a slow or unreachable broker never blocks polling; when the queue is full new
+// messages are dropped. The connection is re-established with backoff when it fails.
+type MQTTSink struct {
+	config  MQTTConfig
+	queue   chan mqttMessage
+	dropped atomic.Uint64
+	// packetID numbers QoS 1 publishes; it is only used by the publishing goroutine
+	packetID uint16
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newMQTTSink starts a sink for the configuration, or returns nil if publishing is not configured
+func newMQTTSink(config *MQTTConfig) *MQTTSink {
+	if !config.enabled() {
+		return nil
+	}
+	s := &MQTTSink{
+		config: *config,
+		queue:  make(chan mqttMessage, mqttQueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write queues a message for every metric of the device without waiting for the broker
+func (s *MQTTSink) Write(data DeviceData, at time.Time) {
+	prefix := s.config.GetTopicPrefix() + "/" + mqttTopicLevel(data.Name) + "/"
+	for key, metric := range data.Metrics {
+		payload, err := json.Marshal(metric)
+		if err != nil {
+			continue
+		}
+		select {
+		case s.queue <- mqttMessage{topic: prefix + mqttTopicLevel(key), payload: payload}:
+		default:
+			if s.dropped.Add(1)%100 == 1 {
+				log.Printf("MQTT queue full, dropped %d messages so far", s.dropped.Load())
+			}
+		}
+	}
+}
+
+// Close sends the messages still queued if the broker is connected, then disconnects
+func (s *MQTTSink) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+// run keeps a connection to the broker and publishes queued messages until the sink is closed
+func (s *MQTTSink) run() {
+	defer close(s.done)
+
+	backoff := time.Second
+	for {
+		conn, err := s.connect()
+		if err != nil {
+			log.Printf("MQTT connection to %s failed, retrying in %v: %v", s.config.Broker, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-s.stop:
+				return
+			}
+			backoff = min(backoff*2, mqttMaxBackoff)
+			continue
+		}
+		log.Printf("Connected to MQTT broker %s", s.config.Broker)
+		backoff = time.Second
+
+		stopped, err := s.publishLoop(conn)
+		conn.Close()
+		if stopped {
+			return
+		}
+		log.Printf("MQTT connection to %s lost, reconnecting: %v", s.config.Broker, err)
+	}
+}
+
+// publishLoop publishes queued messages and keeps the connection alive. It returns true
+// once the sink is closed, or false with the error that broke the connection.
+func (s *MQTTSink) publishLoop(conn net.Conn) (bool, error) {
+	// The broker's responses (PUBACK, PINGRESP) are only read to notice a broken connection
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, conn)
+		if err == nil {
+			err = io.EOF
+		}
+		readErr <- err
+	}()
+
+	ping := time.NewTicker(mqttKeepAlive / 2)
+	defer ping.Stop()
+
+	for {
+		select {
+		case message := <-s.queue:
+			if err := s.write(conn, s.publishPacket(message)); err != nil {
+				// Put the message back so it is sent after reconnecting, unless the queue filled up
+				select {
+				case s.queue <- message:
+				default:
+				}
+				return false, err
+			}
+		case <-ping.C:
+			if err := s.write(conn, []byte{mqttPingReq, 0}); err != nil {
+				return false, err
+			}
+		case err := <-readErr:
+			return false, err
+		case <-s.stop:
+			for {
+				select {
+				case message := <-s.queue:
+					if s.write(conn, s.publishPacket(message)) != nil {
+						return true, nil
+					}
+				default:
+					s.write(conn, []byte{mqttDisconnect, 0})
+					return true, nil
+				}
+			}
+		}
+	}
+}
+
+// connect dials the broker and completes the MQTT handshake
+func (s *MQTTSink) connect() (net.Conn, error) {
+	u, err := url.Parse(s.config.Broker)
+	if err != nil {
+		return nil, err
+	}
+	useTLS := u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts"
+	host := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.write(conn, s.connectPacket()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read CONNACK: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	if ack[0] != mqttConnAck || ack[1] != 2 {
+		conn.Close()
+		return nil, errors.New("unexpected response to CONNECT")
+	}
+	if ack[3] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("broker refused the connection (return code %d)", ack[3])
+	}
+	return conn, nil
+}
+
+// write sends a packet, giving up if the broker doesn't accept it in time
+func (s *MQTTSink) write(conn net.Conn, packet []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := conn.Write(packet)
+	return err
+}
+
+// connectPacket builds a CONNECT packet with a clean session and the configured credentials
+func (s *MQTTSink) connectPacket() []byte {
+	flags := byte(0x02) // clean session
+	if s.config.Username != "" {
+		flags |= 0x80
+	}
+	if s.config.Password != "" {
+		flags |= 0x40
+	}
+
+	keepAlive := uint16(mqttKeepAlive / time.Second)
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = mqttString(body, s.config.GetClientID())
+	if s.config.Username != "" {
+		body = mqttString(body, s.config.Username)
+	}
+	if s.config.Password != "" {
+		body = mqttString(body, s.config.Password)
+	}
+	return mqttPacket(mqttConnect, body)
+}
+
+// publishPacket builds a PUBLISH packet for a message with the configured QoS and retain flag
+func (s *MQTTSink) publishPacket(message mqttMessage) []byte {
+	header := byte(mqttPublish) | byte(s.config.QoS)<<1
+	if s.config.Retain {
+		header |= 0x01
+	}
+	body := mqttString(nil, message.topic)
+	if s.config.QoS > 0 {
+		s.packetID++
+		if s.packetID == 0 {
+			s.packetID = 1
+		}
+		body = append(body, byte(s.packetID>>8), byte(s.packetID))
+	}
+	return mqttPacket(header, append(body, message.payload...))
+}
+
+// mqttPacket prefixes a packet body with its fixed header and variable-length remaining length
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString appends a length-prefixed UTF-8 string
+func mqttString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// mqttTopicLevel makes a device name or metric key safe to use as one topic level
+func mqttTopicLevel(s string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(s)
+}
@@ -0,0 +1,81 @@
+package snmpmonitor
+
+import (
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// OIDStats holds poll statistics for a single OID
+type OIDStats struct {
+	Successes      int64     `json:"successes"`
+	Failures       int64     `json:"failures"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastPoll       time.Time `json:"last_poll"`
+	LastDurationMs float64   `json:"last_duration_ms"`
+	AvgDurationMs  float64   `json:"avg_duration_ms"`
+}
+
+// recordOIDStats updates the statistics of every requested OID after a GET.
+// OIDs are fetched in one batch, so each OID is charged the duration of the request it was part of.
+func (p *Poller) recordOIDStats(oids []string, variables []gosnmp.SnmpPDU, getErr error, elapsed time.Duration) {
+	returned := make(map[string]gosnmp.Asn1BER, len(variables))
+	for _, variable := range variables {
+		returned[variable.Name] = variable.Type
+	}
+
+	now := time.Now()
+	ms := float64(elapsed.Microseconds()) / 1000
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, oid := range oids {
+		stats := p.oidStats[oid]
+		if stats == nil {
+			stats = &OIDStats{}
+			p.oidStats[oid] = stats
+		}
+
+		calls := stats.Successes + stats.Failures
+		stats.AvgDurationMs = (stats.AvgDurationMs*float64(calls) + ms) / float64(calls+1)
+		stats.LastDurationMs = ms
+		stats.LastPoll = now
+
+		pduType, ok := returned[oid]
+		switch {
+		case getErr != nil:
+			stats.Failures++
+			stats.LastError = getErr.Error()
+		case !ok:
+			stats.Failures++
+			stats.LastError = "missing from response"
+		case pduType == gosnmp.NoSuchObject || pduType == gosnmp.NoSuchInstance || pduType == gosnmp.EndOfMibView:
+			stats.Failures++
+			stats.LastError = pduType.String()
+		default:
+			stats.Successes++
+			stats.LastError = ""
+		}
+	}
+}
+
+// GetOIDStats returns a copy of the per-OID poll statistics
+func (p *Poller) GetOIDStats() map[string]OIDStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]OIDStats, len(p.oidStats))
+	for oid, stats := range p.oidStats {
+		result[oid] = *stats
+	}
+	return result
+}
+
+// ResetOIDStats clears the per-OID poll statistics
+func (p *Poller) ResetOIDStats() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.oidStats = make(map[string]*OIDStats)
+}
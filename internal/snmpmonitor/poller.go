@@ -2,31 +2,161 @@ package snmpmonitor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
 )
 
+// maxBisectDepth caps how many times a failing GET batch is split in half
+const maxBisectDepth = 6
+
+// stopTimeout bounds how long Stop waits for an in-flight poll to finish
+const stopTimeout = 10 * time.Second
+
 // Poller handles SNMP polling for a device
 type Poller struct {
 	device     DeviceConfig
-	hubClient  *HubClient
+	hubClient  atomic.Pointer[HubClient]
+	sinks      atomic.Pointer[[]metricSink]
 	stopChan   chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
 	mu         sync.RWMutex
 	running    bool
 	lastValues map[string]float64
+
+	// oidStats holds per-OID response statistics
+	oidStats map[string]*OIDStats
+
+	// extractors holds compiled regex_extract patterns by metric key
+	extractors map[string]*regexp.Regexp
+
+	// synthetic holds compiled match patterns for synthetic metrics by key
+	synthetic map[string]*regexp.Regexp
+
+	// interfaceFilter is the compiled interfaces include pattern, nil to include all
+	interfaceFilter *regexp.Regexp
+
+	// counters holds the previous raw reading of counter metrics
+	counters map[string]counterSample
+
+	// lastLabels holds the enum_map label of the last polled value by metric key
+	lastLabels map[string]string
+
+	// alertState records which event-mode metrics are currently in their alert range
+	alertState map[string]bool
+
+	// nextPoll holds when each metric is next due and lastPolled its last value, for metrics
+	// polled on their own interval. Both are only used by the polling goroutine.
+	nextPoll   map[string]time.Time
+	lastPolled map[string]MetricValue
+
+	// history holds the recent samples of each metric when historySize is positive
+	history     map[string]*metricHistory
+	historySize int
+	// historyGaps records a NaN gap marker for metrics without a value in a poll cycle
+	historyGaps bool
+
+	// roundDigits is the default round_digits of metrics that don't configure rounding
+	roundDigits *int
+
+	// pendingMetrics holds metrics collected since the last send when a send interval is configured
+	pendingMetrics map[string]MetricValue
+
+	consecutiveFailures int
+	lastError           string
+	lastErrorCode       string
+	lastPollTime        time.Time
+	// lastNoData is set when the last poll got an answer but no usable value
+	lastNoData bool
+	// requestedMetrics and usableValues count the configured metrics the current poll
+	// asked for and the values it could use; only the polling goroutine touches them
+	requestedMetrics int
+	usableValues     int
+
+	// limiter caps concurrent polls across all pollers; nil means no limit
+	limiter *pollLimiter
+
+	// capabilities receives the device's probed capabilities every capabilityRefresh.
+	// nextProbe is only used by the polling goroutine.
+	capabilities      *capabilityCache
+	capabilityRefresh time.Duration
+	nextProbe         time.Time
+
+	// includeOID adds the source OID to each metric sent to the hub
+	includeOID bool
+
+	// session is the SNMP session reused across polls. It is only used by the polling goroutine.
+	session *gosnmp.GoSNMP
+	// community is the community the device last answered, if it was found by trying each
+	// of its communities. It is only used by the polling goroutine.
+	community string
+	// communities is the device's community list merged with the default communities.
+	// It is kept apart from device so device stays comparable with the configuration.
+	communities []string
 }
 
 // NewPoller creates a new poller for a device
 func NewPoller(device DeviceConfig, hubClient *HubClient) (*Poller, error) {
-	return &Poller{
-		device:     device,
-		hubClient:  hubClient,
-		stopChan:   make(chan struct{}),
-		lastValues: make(map[string]float64),
-	}, nil
+	p := &Poller{
+		device:         device,
+		stopChan:       make(chan struct{}),
+		done:           make(chan struct{}),
+		lastValues:     make(map[string]float64),
+		lastLabels:     make(map[string]string),
+		extractors:     make(map[string]*regexp.Regexp),
+		synthetic:      make(map[string]*regexp.Regexp),
+		alertState:     make(map[string]bool),
+		counters:       make(map[string]counterSample),
+		oidStats:       make(map[string]*OIDStats),
+		pendingMetrics: make(map[string]MetricValue),
+		nextPoll:       make(map[string]time.Time),
+		lastPolled:     make(map[string]MetricValue),
+		history:        make(map[string]*metricHistory),
+		communities:    device.mergeCommunities(nil),
+	}
+	for name, metric := range device.Metrics {
+		re, err := metric.compileRegexExtract()
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", name, err)
+		}
+		if re != nil {
+			p.extractors[name] = re
+		}
+	}
+	re, err := device.Interfaces.compile()
+	if err != nil {
+		return nil, err
+	}
+	p.interfaceFilter = re
+	for name, synthetic := range device.Synthetic {
+		re, err := synthetic.compile()
+		if err != nil {
+			return nil, fmt.Errorf("synthetic metric %s: %w", name, err)
+		}
+		p.synthetic[name] = re
+	}
+	p.hubClient.Store(hubClient)
+	return p, nil
+}
+
+// SetSinks replaces the outputs that receive the poller's metrics besides the hub
+func (p *Poller) SetSinks(sinks []metricSink) {
+	p.sinks.Store(&sinks)
+}
+
+// SetHubClient swaps the hub client used by the poller without interrupting the polling loop
+func (p *Poller) SetHubClient(hubClient *HubClient) {
+	p.hubClient.Store(hubClient)
 }
 
 // Start starts the polling loop
@@ -34,132 +164,363 @@ func (p *Poller) Start(ctx context.Context) {
 	p.mu.Lock()
 	p.running = true
 	p.mu.Unlock()
+	defer func() {
+		p.Close()
+		p.mu.Lock()
+		p.running = false
+		p.mu.Unlock()
+		close(p.done)
+	}()
 
-	ticker := time.NewTicker(p.device.GetPollInterval())
+	interval := p.device.GetTickInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// A nil channel never fires, so sends follow each poll unless a send interval is configured
+	var sendC <-chan time.Time
+	if sendInterval := p.device.GetSendInterval(); sendInterval > 0 {
+		sendTicker := time.NewTicker(sendInterval)
+		defer sendTicker.Stop()
+		sendC = sendTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
-			p.Stop()
+			p.signalStop()
+			p.flush()
 			return
 		case <-p.stopChan:
+			p.flush()
 			return
+		case <-sendC:
+			p.flush()
 		case <-ticker.C:
-			p.poll()
+			release, ok := p.limiter.acquire(p.stopChan, ctx.Done())
+			if !ok {
+				continue
+			}
+			pollErr := p.poll()
+			if pollErr == nil {
+				p.refreshCapabilities()
+			} else {
+				p.recordHistory(nil, time.Now())
+			}
+			next := p.nextInterval(pollErr)
+			release()
+			if next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
 
-// Stop stops the polling loop
-func (p *Poller) Stop() {
+// nextInterval returns the poll interval to use after a poll with the given result.
+// The first failure keeps the configured interval, since a single lost poll is common,
+// and each further consecutive failure doubles it up to the device's backoff cap.
+// The first success restores the configured interval.
+func (p *Poller) nextInterval(pollErr error) time.Duration {
+	base := p.device.GetTickInterval()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.running {
-		p.running = false
+	if pollErr == nil {
+		if p.consecutiveFailures > 0 {
+			log.Printf("Device %s recovered after %d failed polls", p.device.Name, p.consecutiveFailures)
+		}
+		p.consecutiveFailures = 0
+		return base
+	}
+
+	p.consecutiveFailures++
+	maxInterval := p.device.GetBackoffMax()
+	if maxInterval <= base {
+		return base
+	}
+
+	interval := base
+	for i := 1; i < p.consecutiveFailures && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	log.Printf("Device %s unreachable (%d consecutive failures), next poll in %v", p.device.Name, p.consecutiveFailures, interval)
+	return interval
+}
+
+// Stop stops the polling loop. A poll that is already in flight is allowed to finish
+// and any values not yet sent are flushed to the hub. Stop waits for this to
+// complete for at most stopTimeout.
+func (p *Poller) Stop() {
+	p.signalStop()
+
+	p.mu.RLock()
+	running := p.running
+	p.mu.RUnlock()
+	if !running {
+		return
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(stopTimeout):
+		log.Printf("Poller for device %s did not stop within %v", p.device.Name, stopTimeout)
+	}
+}
+
+// signalStop tells the polling loop to exit without waiting for it
+func (p *Poller) signalStop() {
+	p.stopOnce.Do(func() {
 		close(p.stopChan)
+	})
+}
+
+// connect returns the poller's SNMP session, opening it if there is none. The session
+// is kept open across polls and only reopened after a failed poll.
+func (p *Poller) connect() (*gosnmp.GoSNMP, error) {
+	if p.session != nil {
+		return p.session, nil
+	}
+
+	params, err := snmpClientFor(&p.device, p.currentCommunity())
+	if err != nil {
+		return nil, err
 	}
+	p.session = params
+	return params, nil
 }
 
-// poll performs a single SNMP poll
-func (p *Poller) poll() {
-	params := &gosnmp.GoSNMP{
-		Target:    p.device.IP,
-		Port:      161,
-		Community: p.device.Community,
-		Version:   gosnmp.Version2c,
-		Timeout:   5 * time.Second,
-		Retries:   1,
+// Close closes the poller's SNMP session. It is called when the polling loop exits.
+func (p *Poller) Close() {
+	if p.session != nil {
+		p.session.Conn.Close()
+		p.session = nil
 	}
+}
+
+// errConnect wraps the error of a poll that failed to open a session to the device
+var errConnect = errors.New("connect")
 
-	if err := params.Connect(); err != nil {
+// poll performs a single SNMP poll and returns an error if the device could not be reached
+func (p *Poller) poll() (err error) {
+	p.requestedMetrics, p.usableValues = 0, 0
+	defer p.recordPollResult(&err)
+
+	params, err := p.connect()
+	if err != nil {
 		log.Printf("Failed to connect to %s: %v", p.device.IP, err)
-		return
+		return fmt.Errorf("%w: %w", errConnect, err)
 	}
-	defer params.Conn.Close()
+	// Drop the session after a failure so the next poll starts with a fresh socket
+	defer func() {
+		if err != nil {
+			p.Close()
+		}
+	}()
 
-	// Collect OIDs to poll
-	var oids []string
-	for _, metric := range p.device.Metrics {
-		oids = append(oids, metric.OID)
+	if p.device.Mode == ModeEntitySensors {
+		metrics, err := p.pollEntitySensors(params)
+		if err != nil && p.findCommunity(params) {
+			metrics, err = p.pollEntitySensors(params)
+		}
+		if err != nil {
+			log.Printf("Entity sensor walk failed for %s: %v", p.device.IP, err)
+			return err
+		}
+		p.publish(metrics)
+		return nil
 	}
 
-	if len(oids) == 0 {
-		return
+	if p.device.Mode == ModeInterfaces {
+		metrics, err := p.pollInterfaces(params)
+		if err != nil && p.findCommunity(params) {
+			metrics, err = p.pollInterfaces(params)
+		}
+		if err != nil {
+			log.Printf("Interface walk failed for %s: %v", p.device.IP, err)
+			return err
+		}
+		p.publish(metrics)
+		return nil
 	}
 
-	// Perform SNMP GET request
-	result, err := params.Get(oids)
-	if err != nil {
-		log.Printf("SNMP GET failed for %s: %v", p.device.IP, err)
-		return
+	// Collect the OIDs of the metrics that are due, each requested once however many
+	// metrics read it. Walked tables are read separately.
+	polledAt := time.Now()
+	due := p.dueMetrics(polledAt)
+	p.requestedMetrics = len(due)
+	oidKeys := make(map[string][]string)
+	var oids []string
+	for key := range due {
+		metric := p.device.Metrics[key]
+		if metric.Walk {
+			continue
+		}
+		if _, ok := oidKeys[metric.OID]; !ok {
+			oids = append(oids, metric.OID)
+		}
+		oidKeys[metric.OID] = append(oidKeys[metric.OID], key)
 	}
 
-	// Process results
 	metrics := make(map[string]MetricValue)
-	for _, variable := range result.Variables {
-		oid := variable.Name
-
-		// Find the metric config for this OID
-		var metricConfig MetricConfig
-		var metricName string
-		found := false
-
-		for name, metric := range p.device.Metrics {
-			if metric.OID == oid {
-				metricConfig = metric
-				metricName = name
-				found = true
-				break
-			}
+	if len(oids) > 0 {
+		// Perform SNMP GET or GETBULK request
+		start := time.Now()
+		variables, err := p.fetch(params, oids)
+		if err != nil && p.findCommunity(params) {
+			variables, err = p.fetch(params, oids)
+		}
+		p.recordOIDStats(oids, variables, err, time.Since(start))
+		if err != nil {
+			log.Printf("SNMP GET failed for %s: %v", p.device.IP, err)
+			return fmt.Errorf("get: %w", err)
 		}
 
-		if !found {
-			continue
+		// Process results
+		now := time.Now()
+		for _, variable := range variables {
+			// Fill every metric that reads this OID
+			for _, name := range oidKeys[variable.Name] {
+				if metric, ok := p.buildMetric(name, name, p.device.Metrics[name], variable, now); ok {
+					metrics[name] = metric
+				}
+			}
 		}
+	}
 
-		// Convert value to float64
-		value := p.convertSNMPValue(variable.Value)
-		if value == nil {
-			continue
+	// A failed walk only costs the rows of its table: the values already read are still
+	// published. The poll only fails when nothing at all could be read.
+	var walkErr error
+	for key := range due {
+		if metricConfig := p.device.Metrics[key]; metricConfig.Walk {
+			if err := p.walk(params, key, metricConfig, time.Now(), metrics); err != nil {
+				log.Printf("SNMP walk of %s failed for %s: %v", metricConfig.OID, p.device.IP, err)
+				walkErr = fmt.Errorf("walk %s: %w", key, err)
+			}
 		}
+	}
+	if walkErr != nil && len(metrics) == 0 {
+		return walkErr
+	}
+
+	p.schedule(metrics, due, polledAt)
+	p.publish(metrics)
+	return nil
+}
 
-		// Apply scaling
-		scaledValue := *value * metricConfig.Scale
-		if metricConfig.Scale == 0 {
-			scaledValue = *value
+// buildMetric converts a polled variable to the metric stored under key, applying the
+// configuration of the metric configKey. It returns false if the value can't be converted.
+func (p *Poller) buildMetric(configKey, key string, metricConfig MetricConfig, variable gosnmp.SnmpPDU, now time.Time) (MetricValue, bool) {
+	// Convert value to float64, extracting it from string responses or computing a counter rate if configured
+	var value *float64
+	if metricConfig.isCounter() {
+		value = p.counterRate(key, metricConfig, variable, now)
+		if value == nil && convertSNMPValue(variable.Value) != nil {
+			// The first reading of a counter only sets the baseline of its rate
+			p.usableValues++
 		}
+	} else if re, ok := p.extractors[configKey]; ok {
+		value = extractValue(re, variable.Value)
+	} else {
+		value = convertSNMPValue(variable.Value)
+	}
+	if value == nil {
+		return MetricValue{}, false
+	}
+	p.usableValues++
 
-		// Store the value
-		p.mu.Lock()
-		p.lastValues[metricName] = scaledValue
-		p.mu.Unlock()
+	// Apply scaling and rounding
+	scaledValue := transformValue(*value, metricConfig.withDefaultRounding(p.roundDigits))
+	label, hasLabel := metricConfig.enumLabel(*value)
+
+	// Store the value
+	p.mu.Lock()
+	p.lastValues[key] = scaledValue
+	if hasLabel {
+		p.lastLabels[key] = label
+	} else {
+		delete(p.lastLabels, key)
+	}
+	p.mu.Unlock()
+
+	// Create metric value for hub
+	metric := MetricValue{
+		Name:     metricConfig.Name,
+		Value:    scaledValue,
+		Unit:     metricConfig.Unit,
+		Category: metricConfig.Category,
+		Group:    metricConfig.SensorGroup,
+		Label:    label,
+	}
+	if p.includeOID {
+		metric.OID = variable.Name
+	}
+	return metric, true
+}
+
+// publish adds synthetic metrics, records the history, drops event-mode metrics that didn't
+// cross a threshold and sends the rest to the hub, or buffers them when a send interval is configured
+func (p *Poller) publish(metrics map[string]MetricValue) {
+	if len(metrics) == 0 {
+		p.recordHistory(metrics, time.Now())
+		return
+	}
+	p.computeSynthetic(metrics)
+	p.recordHistory(metrics, time.Now())
+	p.filterEvents(metrics)
+	if len(metrics) == 0 {
+		return
+	}
 
-		// Create metric value for hub
-		metrics[metricName] = MetricValue{
-			Name:     metricConfig.Name,
-			Value:    scaledValue,
-			Unit:     metricConfig.Unit,
-			Category: metricConfig.Category,
+	// With a send interval, keep the latest values until the send ticker fires
+	if p.device.GetSendInterval() > 0 {
+		p.mu.Lock()
+		for name, metric := range metrics {
+			p.pendingMetrics[name] = metric
 		}
+		p.mu.Unlock()
+		return
 	}
 
-	// Send metrics to hub
+	p.send(metrics)
+}
+
+// flush sends the metrics collected since the last send interval
+func (p *Poller) flush() {
+	p.mu.Lock()
+	metrics := p.pendingMetrics
+	p.pendingMetrics = make(map[string]MetricValue)
+	p.mu.Unlock()
+
 	if len(metrics) > 0 {
-		deviceData := DeviceData{
-			Name:    p.device.Name,
-			IP:      p.device.IP,
-			Metrics: metrics,
-		}
+		p.send(metrics)
+	}
+}
+
+// send pushes metrics to the hub
+func (p *Poller) send(metrics map[string]MetricValue) {
+	deviceData := DeviceData{
+		Name:    p.device.Name,
+		IP:      p.device.IP,
+		Metrics: metrics,
+	}
+
+	// Use NotifyDevice to create per-device connections
+	p.hubClient.Load().NotifyDevice(deviceData)
 
-		// Use NotifyDevice to create per-device connections
-		p.hubClient.NotifyDevice(deviceData)
+	if sinks := p.sinks.Load(); sinks != nil {
+		now := time.Now()
+		for _, sink := range *sinks {
+			sink.Write(deviceData, now)
+		}
 	}
 }
 
 // convertSNMPValue converts SNMP value to float64
-func (p *Poller) convertSNMPValue(value interface{}) *float64 {
+func convertSNMPValue(value interface{}) *float64 {
 	switch v := value.(type) {
 	case int:
 		f := float64(v)
@@ -189,6 +550,117 @@ func (p *Poller) convertSNMPValue(value interface{}) *float64 {
 	}
 }
 
+// fetch retrieves the OIDs with GET or GETBULK, escalating through retry_timeouts
+// when they are configured.
+func (p *Poller) fetch(params *gosnmp.GoSNMP, oids []string) ([]gosnmp.SnmpPDU, error) {
+	request := func() ([]gosnmp.SnmpPDU, error) {
+		if p.device.useGetBulk(len(oids)) {
+			return p.getBulk(params, oids)
+		}
+		return p.get(params, oids, 0)
+	}
+
+	var variables []gosnmp.SnmpPDU
+	err := requestWithRetryTimeouts(params, p.device.GetRetryTimeouts(), func() (err error) {
+		variables, err = request()
+		return err
+	})
+	return variables, err
+}
+
+// get fetches the OIDs in a single GET. If the request fails and bisect_on_error is
+// enabled, the batch is split in half and each half retried, up to maxBisectDepth
+// times, so one misbehaving OID doesn't cost the values of all the others.
+// Timeouts are not bisected since they usually mean the whole device is unreachable.
+func (p *Poller) get(params *gosnmp.GoSNMP, oids []string, depth int) ([]gosnmp.SnmpPDU, error) {
+	result, err := params.Get(oids)
+	if err == nil && result.Error != gosnmp.NoError {
+		err = &pduError{status: result.Error}
+	}
+	if err == nil {
+		return result.Variables, nil
+	}
+
+	if !p.device.BisectOnError || isTimeout(err) || depth >= maxBisectDepth {
+		return nil, err
+	}
+	if len(oids) == 1 {
+		log.Printf("Isolated failing OID %s on device %s: %v", oids[0], p.device.Name, err)
+		return nil, err
+	}
+
+	mid := len(oids) / 2
+	left, leftErr := p.get(params, oids[:mid], depth+1)
+	right, rightErr := p.get(params, oids[mid:], depth+1)
+	if leftErr != nil && rightErr != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// isTimeout reports whether err is an SNMP request timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return strings.Contains(err.Error(), "timeout")
+}
+
+// pduError is an error-status other than noError returned in a device's response PDU
+type pduError struct {
+	status gosnmp.SNMPError
+}
+
+func (e *pduError) Error() string {
+	return fmt.Sprintf("device returned %v", e.status)
+}
+
+// errorCode classifies a poll error: timeout, connect, or the RFC 3416 name of the
+// error-status the device returned (noSuchName, tooBig, authorizationError, ...).
+// It returns "" for errors that fit none of these.
+func errorCode(err error) string {
+	var pduErr *pduError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &pduErr):
+		name := pduErr.status.String()
+		if strings.HasPrefix(name, "SNMPError(") {
+			return "errorStatus" + strconv.Itoa(int(pduErr.status))
+		}
+		return strings.ToLower(name[:1]) + name[1:]
+	case isTimeout(err):
+		return "timeout"
+	case errors.Is(err, errConnect):
+		return "connect"
+	}
+	return ""
+}
+
+// extractValue parses a number from the first capture group of re matched against a string SNMP value
+func extractValue(re *regexp.Regexp, value interface{}) *float64 {
+	var text string
+	switch v := value.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return nil
+	}
+
+	match := re.FindStringSubmatch(text)
+	if len(match) < 2 {
+		return nil
+	}
+	f, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
 // GetLastValues returns the last polled values
 func (p *Poller) GetLastValues() map[string]float64 {
 	p.mu.RLock()
@@ -201,19 +673,89 @@ func (p *Poller) GetLastValues() map[string]float64 {
 	return result
 }
 
-// GetStatus returns the current status of the poller
-func (p *Poller) GetStatus() string {
+// IsUp reports whether the poller is running and its last poll succeeded
+func (p *Poller) IsUp() bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	if !p.running {
-		return "Stopped"
+	return p.running && p.consecutiveFailures == 0 && len(p.lastValues) > 0
+}
+
+// recordPollResult records the time and outcome of the poll that returned *err. A
+// successful poll also refreshes the timestamp of the data held for the hub.
+func (p *Poller) recordPollResult(err *error) {
+	p.mu.Lock()
+	defer func() {
+		succeeded := *err == nil && !p.lastNoData
+		polled := p.lastPollTime
+		p.mu.Unlock()
+		if succeeded {
+			p.hubClient.Load().MarkPolled(p.device.IP, polled)
+		}
+	}()
+
+	p.lastPollTime = time.Now()
+	p.lastNoData = false
+	switch {
+	case *err != nil:
+		p.lastError = (*err).Error()
+		p.lastErrorCode = errorCode(*err)
+	case p.requestedMetrics > 0 && p.usableValues == 0:
+		// The device answered, so this is not a failure, but none of its values could be
+		// used: usually wrong OIDs or values of the wrong type
+		p.lastNoData = true
+		p.lastError = fmt.Sprintf("device answered but none of the %d requested metrics had a usable value", p.requestedMetrics)
+		p.lastErrorCode = "noData"
+	default:
+		p.lastError = ""
+		p.lastErrorCode = ""
 	}
+}
+
+// Poll statuses returned by GetStatus
+const (
+	StatusOK          = "ok"
+	StatusNoData      = "no data"
+	StatusUnreachable = "unreachable"
+	StatusNeverPolled = "never polled"
+)
 
-	if len(p.lastValues) == 0 {
-		return "Starting"
+// GetStatus returns the outcome of the last poll: ok, no data when the device answered
+// without a usable value, unreachable, or never polled
+func (p *Poller) GetStatus() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.lastPollTime.IsZero() {
+		return StatusNeverPolled
+	}
+	if p.lastNoData {
+		return StatusNoData
 	}
+	if p.lastError != "" {
+		return StatusUnreachable
+	}
+	return StatusOK
+}
 
-	// Check if we have recent data (within last 2 poll intervals)
-	return "Connected"
+// PollInfo describes the most recent polls of a device
+type PollInfo struct {
+	LastError           string    `json:"last_error,omitempty"`
+	LastPollTime        time.Time `json:"last_poll_time,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	// ErrorCode classifies LastError: timeout, connect, noData, or the error-status the device returned
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// GetPollInfo returns the last poll time and error and the number of consecutive failed polls
+func (p *Poller) GetPollInfo() PollInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return PollInfo{
+		LastError:           p.lastError,
+		LastPollTime:        p.lastPollTime,
+		ConsecutiveFailures: p.consecutiveFailures,
+		ErrorCode:           p.lastErrorCode,
+	}
 }
@@ -0,0 +1,40 @@
+package snmpmonitor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextIntervalBackoff(t *testing.T) {
+	p := &Poller{device: DeviceConfig{Name: "ups", PollInterval: 10, BackoffMax: 60}}
+	errTimeout := errors.New("request timeout")
+
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{"first failure keeps the interval", errTimeout, 10 * time.Second},
+		{"second failure doubles", errTimeout, 20 * time.Second},
+		{"third failure doubles again", errTimeout, 40 * time.Second},
+		{"fourth failure is capped", errTimeout, 60 * time.Second},
+		{"fifth failure stays capped", errTimeout, 60 * time.Second},
+		{"success restores the interval", nil, 10 * time.Second},
+		{"failure after recovery starts over", errTimeout, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.nextInterval(tt.err); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNextIntervalWithoutBackoff(t *testing.T) {
+	p := &Poller{device: DeviceConfig{Name: "ups", PollInterval: 10}}
+	for i := 0; i < 3; i++ {
+		if got := p.nextInterval(errors.New("request timeout")); got != 10*time.Second {
+			t.Errorf("failure %d: got %v, want 10s", i+1, got)
+		}
+	}
+}
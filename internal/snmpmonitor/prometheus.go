@@ -0,0 +1,133 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// promSample is one labelled value of a Prometheus metric
+type promSample struct {
+	device string
+	ip     string
+	value  float64
+}
+
+// handlePrometheus renders the latest polled values in the Prometheus text exposition format
+func (ws *WebServer) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	config := ws.agent.GetConfig()
+
+	samples := make(map[string][]promSample)
+	help := make(map[string]string)
+	for _, device := range config.Devices {
+		if device.Disabled {
+			continue
+		}
+		_, values := ws.agent.GetPollerStatus(device.Name)
+		for key, value := range values {
+			category, name := "", key
+			if metric, ok := device.Metrics[key]; ok {
+				category, name = metric.Category, metric.Name
+			} else if synthetic, ok := device.Synthetic[key]; ok {
+				category, name = synthetic.Category, synthetic.Name
+			}
+			if name == "" {
+				name = key
+			}
+
+			metricName := promMetricName(category, name)
+			samples[metricName] = append(samples[metricName], promSample{device: device.Name, ip: device.IP, value: value})
+			if _, ok := help[metricName]; !ok {
+				help[metricName] = name
+			}
+		}
+	}
+
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, promEscape(help[name], false))
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+		for _, s := range samples[name] {
+			fmt.Fprintf(&b, "%s{device=\"%s\",ip=\"%s\"} %s\n", name, promEscape(s.device, true), promEscape(s.ip, true),
+				strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+
+	if ws.config.RuntimeMetrics {
+		ws.writeRuntimeMetrics(&b)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// writeRuntimeMetrics writes the monitor process's own Go runtime metrics, prefixed snmp_monitor_
+func (ws *WebServer) writeRuntimeMetrics(b *strings.Builder) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	metrics := []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"snmp_monitor_goroutines", "gauge", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())},
+		{"snmp_monitor_memory_alloc_bytes", "gauge", "Bytes of allocated heap objects.", float64(mem.Alloc)},
+		{"snmp_monitor_memory_sys_bytes", "gauge", "Bytes of memory obtained from the OS.", float64(mem.Sys)},
+		{"snmp_monitor_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.", float64(mem.HeapInuse)},
+		{"snmp_monitor_heap_objects", "gauge", "Number of allocated heap objects.", float64(mem.HeapObjects)},
+		{"snmp_monitor_gc_cycles_total", "counter", "Number of completed GC cycles.", float64(mem.NumGC)},
+		{"snmp_monitor_gc_pause_seconds_total", "counter", "Total time spent in GC stop-the-world pauses.", float64(mem.PauseTotalNs) / 1e9},
+		{"snmp_monitor_start_time_seconds", "gauge", "Start time of the monitor since unix epoch in seconds.", float64(ws.agent.startedAt.UnixNano()) / 1e9},
+	}
+	for _, m := range metrics {
+		fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(b, "# TYPE %s %s\n", m.name, m.kind)
+		fmt.Fprintf(b, "%s %s\n", m.name, strconv.FormatFloat(m.value, 'g', -1, 64))
+	}
+}
+
+// promMetricName builds a Prometheus metric name such as snmp_temperature_inlet_temp
+func promMetricName(category, name string) string {
+	parts := []string{"snmp"}
+	for _, part := range []string{category, name} {
+		if part = promSanitize(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// promSanitize lowercases s and replaces characters not allowed in metric names with underscores
+func promSanitize(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// promEscape escapes a HELP text or, when label is set, a label value
+func promEscape(s string, label bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if label {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"time"
+)
+
+// validatePollInterval checks the metric's poll interval override
+func (m *MetricConfig) validatePollInterval() error {
+	if m.PollInterval < 0 {
+		return fmt.Errorf("poll_interval_sec must not be negative")
+	}
+	return nil
+}
+
+// metricPollInterval returns how often a metric is polled: its own poll_interval_sec, or the device's
+func (d *DeviceConfig) metricPollInterval(metric MetricConfig) time.Duration {
+	if metric.PollInterval > 0 {
+		return time.Duration(metric.PollInterval) * time.Second
+	}
+	return d.GetPollInterval()
+}
+
+// GetTickInterval returns how often the poller wakes up: the shortest of the device's
+// poll interval and the poll intervals of its metrics
+func (d *DeviceConfig) GetTickInterval() time.Duration {
+	interval := d.GetPollInterval()
+	for _, metric := range d.Metrics {
+		interval = min(interval, d.metricPollInterval(metric))
+	}
+	return interval
+}
+
+// dueMetrics returns the keys of the metrics due to be polled at now. A metric is due when
+// its next poll is less than half a tick away, so a tick that fires slightly early doesn't
+// delay it by a whole interval.
+func (p *Poller) dueMetrics(now time.Time) map[string]bool {
+	slack := p.device.GetTickInterval() / 2
+	due := make(map[string]bool, len(p.device.Metrics))
+	for key := range p.device.Metrics {
+		if p.nextPoll[key].Sub(now) < slack {
+			due[key] = true
+		}
+	}
+	return due
+}
+
+// schedule sets the next poll time of the polled metrics and merges in the last values of
+// the metrics that were not due, so every report carries the device's full set of metrics
+func (p *Poller) schedule(metrics map[string]MetricValue, due map[string]bool, now time.Time) {
+	for key := range due {
+		p.nextPoll[key] = now.Add(p.device.metricPollInterval(p.device.Metrics[key]))
+	}
+	for key, metric := range metrics {
+		p.lastPolled[key] = metric
+	}
+	for key, metric := range p.lastPolled {
+		if _, ok := metrics[key]; ok {
+			continue
+		}
+		// A due metric that wasn't returned, or a table row that is gone, is not reported again
+		if due[p.configKey(key)] {
+			delete(p.lastPolled, key)
+			continue
+		}
+		metrics[key] = metric
+	}
+}
@@ -0,0 +1,158 @@
+package snmpmonitor
+
+import "strings"
+
+// redactedMask replaces the hidden part of a redacted secret
+const redactedMask = "****"
+
+// redactSecret masks a secret for display, keeping the last 4 characters of long secrets
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return redactedMask
+	}
+	return redactedMask + secret[len(secret)-4:]
+}
+
+// redactedHubConfig returns a copy of the hub configuration with the token and key masked
+func redactedHubConfig(hub *HubConfig) *HubConfig {
+	if hub == nil {
+		return nil
+	}
+	redacted := *hub
+	redacted.Token = redactSecret(hub.Token)
+	redacted.Key = redactSecret(hub.Key)
+	return &redacted
+}
+
+// redactedWebServerConfig returns a copy of the web server configuration with the auth password and token masked
+func redactedWebServerConfig(webServer *WebServerConfig) *WebServerConfig {
+	if webServer == nil || webServer.Auth == nil {
+		return webServer
+	}
+	redacted := *webServer
+	auth := *webServer.Auth
+	auth.Password = redactSecret(auth.Password)
+	auth.Token = redactSecret(auth.Token)
+	redacted.Auth = &auth
+	return &redacted
+}
+
+// restoreRedactedAuth keeps the current auth password and token when an update sends back their masked values
+func restoreRedactedAuth(webServer, current *WebServerConfig) {
+	if webServer == nil || webServer.Auth == nil || current == nil || current.Auth == nil {
+		return
+	}
+	if isRedacted(webServer.Auth.Password, current.Auth.Password) {
+		webServer.Auth.Password = current.Auth.Password
+	}
+	if isRedacted(webServer.Auth.Token, current.Auth.Token) {
+		webServer.Auth.Token = current.Auth.Token
+	}
+}
+
+// restoreRedactedSecrets keeps the current token and key when an update sends back their masked values
+func restoreRedactedSecrets(hub, current *HubConfig) {
+	if hub == nil || current == nil {
+		return
+	}
+	if isRedacted(hub.Token, current.Token) {
+		hub.Token = current.Token
+	}
+	if isRedacted(hub.Key, current.Key) {
+		hub.Key = current.Key
+	}
+}
+
+// redactedInfluxConfig returns a copy of the InfluxDB configuration with the token masked
+func redactedInfluxConfig(influx *InfluxConfig) *InfluxConfig {
+	if influx == nil {
+		return nil
+	}
+	redacted := *influx
+	redacted.Token = redactSecret(influx.Token)
+	return &redacted
+}
+
+// restoreRedactedInflux keeps the current InfluxDB token when an update sends back its masked value
+func restoreRedactedInflux(influx, current *InfluxConfig) {
+	if influx == nil || current == nil {
+		return
+	}
+	if isRedacted(influx.Token, current.Token) {
+		influx.Token = current.Token
+	}
+}
+
+// redactedMQTTConfig returns a copy of the MQTT configuration with the password masked
+func redactedMQTTConfig(mqtt *MQTTConfig) *MQTTConfig {
+	if mqtt == nil {
+		return nil
+	}
+	redacted := *mqtt
+	redacted.Password = redactSecret(mqtt.Password)
+	return &redacted
+}
+
+// restoreRedactedMQTT keeps the current MQTT password when an update sends back its masked value
+func restoreRedactedMQTT(mqtt, current *MQTTConfig) {
+	if mqtt == nil || current == nil {
+		return
+	}
+	if isRedacted(mqtt.Password, current.Password) {
+		mqtt.Password = current.Password
+	}
+}
+
+// redactedDevice returns a copy of the device with its SNMPv3 passphrases masked
+func redactedDevice(device DeviceConfig) DeviceConfig {
+	if device.SNMPv3 != nil {
+		snmpv3 := *device.SNMPv3
+		snmpv3.AuthPassphrase = redactSecret(snmpv3.AuthPassphrase)
+		snmpv3.PrivPassphrase = redactSecret(snmpv3.PrivPassphrase)
+		device.SNMPv3 = &snmpv3
+	}
+	return device
+}
+
+// redactedDevices returns a copy of the devices with their SNMPv3 passphrases masked
+func redactedDevices(devices []DeviceConfig) []DeviceConfig {
+	if devices == nil {
+		return nil
+	}
+	redacted := make([]DeviceConfig, len(devices))
+	for i, device := range devices {
+		redacted[i] = redactedDevice(device)
+	}
+	return redacted
+}
+
+// restoreRedactedDevices keeps the current SNMPv3 passphrases of devices, matched by name,
+// when an update sends back their masked values
+func restoreRedactedDevices(devices, current []DeviceConfig) {
+	for i := range devices {
+		snmpv3 := devices[i].SNMPv3
+		if snmpv3 == nil {
+			continue
+		}
+		for _, existing := range current {
+			if existing.Name != devices[i].Name || existing.SNMPv3 == nil {
+				continue
+			}
+			if isRedacted(snmpv3.AuthPassphrase, existing.SNMPv3.AuthPassphrase) {
+				snmpv3.AuthPassphrase = existing.SNMPv3.AuthPassphrase
+			}
+			if isRedacted(snmpv3.PrivPassphrase, existing.SNMPv3.PrivPassphrase) {
+				snmpv3.PrivPassphrase = existing.SNMPv3.PrivPassphrase
+			}
+			break
+		}
+	}
+}
+
+// isRedacted reports whether value is the masked form of secret
+func isRedacted(value, secret string) bool {
+	return strings.HasPrefix(value, redactedMask) && value == redactSecret(secret)
+}
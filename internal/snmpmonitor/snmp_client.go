@@ -0,0 +1,94 @@
+package snmpmonitor
+
+import (
+	"log"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	// snmpTimeout is the timeout of each SNMP request unless retry_timeouts overrides it
+	snmpTimeout = 5 * time.Second
+	// snmpRetries is the number of times gosnmp resends a request that timed out
+	snmpRetries = 1
+)
+
+// snmpClientFor opens a UDP session to a device with the given community, or with its
+// SNMPv3 credentials when those are set. The pollers and the web endpoints that query
+// devices all connect through here, so they resolve credentials, timeouts and transport
+// the same way.
+func snmpClientFor(device *DeviceConfig, community string) (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{
+		Target:    device.IP,
+		Port:      device.GetPort(),
+		Community: community,
+		Version:   gosnmp.Version2c,
+		Timeout:   snmpTimeout,
+		Retries:   snmpRetries,
+	}
+	if device.SNMPv3 != nil {
+		device.SNMPv3.apply(params)
+	}
+	if err := params.Connect(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// requestWithRetryTimeouts runs request on the session. With timeouts given, a request that
+// times out is reissued with each of them in turn instead of relying on the session's fixed
+// timeout and retries, which are restored afterwards since the session is reused.
+func requestWithRetryTimeouts(params *gosnmp.GoSNMP, timeouts []time.Duration, request func() error) error {
+	if len(timeouts) == 0 {
+		return request()
+	}
+
+	defer func(timeout time.Duration, retries int) {
+		params.Timeout, params.Retries = timeout, retries
+	}(params.Timeout, params.Retries)
+
+	params.Retries = 0
+	var err error
+	for i, timeout := range timeouts {
+		params.Timeout = timeout
+		err = request()
+		if err == nil || !isTimeout(err) {
+			break
+		}
+		if i < len(timeouts)-1 {
+			log.Printf("SNMP request to %s timed out after %v, retrying with %v", params.Target, timeout, timeouts[i+1])
+		}
+	}
+	return err
+}
+
+// connectDevice opens a session for a web endpoint the way a poller does. It connects with
+// the first of the device's communities merged with the default communities, checks the
+// session with a request that escalates through retry_timeouts like a poller's GET, and
+// when the device doesn't answer, switches to the first other community it does answer.
+func (ws *WebServer) connectDevice(device *DeviceConfig) (*gosnmp.GoSNMP, error) {
+	communities := device.mergeCommunities(ws.agent.GetConfig().GetDefaults().Communities)
+	community := ""
+	if len(communities) > 0 {
+		community = communities[0]
+	}
+
+	params, err := snmpClientFor(device, community)
+	if err != nil {
+		return nil, err
+	}
+	err = requestWithRetryTimeouts(params, device.GetRetryTimeouts(), func() error {
+		return checkSession(params)
+	})
+	if err != nil && device.SNMPv3 == nil {
+		if _, ok := switchCommunity(params, communities); ok {
+			err = nil
+		}
+	}
+	if err != nil {
+		params.Conn.Close()
+		return nil, err
+	}
+	return params, nil
+}
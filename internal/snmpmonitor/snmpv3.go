@@ -0,0 +1,147 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// SNMPv3Config defines the user-based security settings for an SNMPv3 device
+type SNMPv3Config struct {
+	SecurityName string `json:"security_name"`
+	// SecurityLevel is noAuthNoPriv, authNoPriv or authPriv. When empty it follows from
+	// which protocols are set.
+	SecurityLevel string `json:"security_level,omitempty"`
+	// AuthProtocol is MD5 or SHA. Leave empty for noAuthNoPriv.
+	AuthProtocol   string `json:"auth_protocol,omitempty"`
+	AuthPassphrase string `json:"auth_passphrase,omitempty"`
+	// PrivProtocol is DES or AES. Requires an auth protocol.
+	PrivProtocol   string `json:"priv_protocol,omitempty"`
+	PrivPassphrase string `json:"priv_passphrase,omitempty"`
+}
+
+// authProtocol maps the configured auth protocol to its gosnmp value
+func (c *SNMPv3Config) authProtocol() (gosnmp.SnmpV3AuthProtocol, error) {
+	switch strings.ToUpper(c.AuthProtocol) {
+	case "":
+		return gosnmp.NoAuth, nil
+	case "MD5":
+		return gosnmp.MD5, nil
+	case "SHA":
+		return gosnmp.SHA, nil
+	default:
+		return gosnmp.NoAuth, fmt.Errorf("invalid auth_protocol %q: must be MD5 or SHA", c.AuthProtocol)
+	}
+}
+
+// privProtocol maps the configured privacy protocol to its gosnmp value
+func (c *SNMPv3Config) privProtocol() (gosnmp.SnmpV3PrivProtocol, error) {
+	switch strings.ToUpper(c.PrivProtocol) {
+	case "":
+		return gosnmp.NoPriv, nil
+	case "DES":
+		return gosnmp.DES, nil
+	case "AES":
+		return gosnmp.AES, nil
+	default:
+		return gosnmp.NoPriv, fmt.Errorf("invalid priv_protocol %q: must be DES or AES", c.PrivProtocol)
+	}
+}
+
+// securityLevel maps the configured security level to its gosnmp message flags. Without
+// one, the level follows from which protocols are set.
+func (c *SNMPv3Config) securityLevel() (gosnmp.SnmpV3MsgFlags, error) {
+	switch strings.ToLower(c.SecurityLevel) {
+	case "":
+		switch {
+		case c.PrivProtocol != "":
+			return gosnmp.AuthPriv, nil
+		case c.AuthProtocol != "":
+			return gosnmp.AuthNoPriv, nil
+		default:
+			return gosnmp.NoAuthNoPriv, nil
+		}
+	case "noauthnopriv":
+		return gosnmp.NoAuthNoPriv, nil
+	case "authnopriv":
+		return gosnmp.AuthNoPriv, nil
+	case "authpriv":
+		return gosnmp.AuthPriv, nil
+	default:
+		return gosnmp.NoAuthNoPriv, fmt.Errorf("invalid security_level %q: must be noAuthNoPriv, authNoPriv or authPriv", c.SecurityLevel)
+	}
+}
+
+// validate checks that the SNMPv3 settings form a usable security level
+func (c *SNMPv3Config) validate() error {
+	if c.SecurityName == "" {
+		return fmt.Errorf("snmpv3: security_name is required")
+	}
+	auth, err := c.authProtocol()
+	if err != nil {
+		return fmt.Errorf("snmpv3: %w", err)
+	}
+	priv, err := c.privProtocol()
+	if err != nil {
+		return fmt.Errorf("snmpv3: %w", err)
+	}
+	level, err := c.securityLevel()
+	if err != nil {
+		return fmt.Errorf("snmpv3: %w", err)
+	}
+	if priv != gosnmp.NoPriv && auth == gosnmp.NoAuth {
+		return fmt.Errorf("snmpv3: priv_protocol %s requires an auth_protocol", c.PrivProtocol)
+	}
+	if auth == gosnmp.NoAuth && c.AuthPassphrase != "" {
+		return fmt.Errorf("snmpv3: auth_passphrase is set without an auth_protocol")
+	}
+	if priv == gosnmp.NoPriv && c.PrivPassphrase != "" {
+		return fmt.Errorf("snmpv3: priv_passphrase is set without a priv_protocol")
+	}
+	if auth != gosnmp.NoAuth && c.AuthPassphrase == "" {
+		return fmt.Errorf("snmpv3: auth_passphrase is required with auth_protocol %s", c.AuthProtocol)
+	}
+	if priv != gosnmp.NoPriv && c.PrivPassphrase == "" {
+		return fmt.Errorf("snmpv3: priv_passphrase is required with priv_protocol %s", c.PrivProtocol)
+	}
+
+	switch level {
+	case gosnmp.NoAuthNoPriv:
+		if auth != gosnmp.NoAuth {
+			return fmt.Errorf("snmpv3: security_level %s does not take an auth_protocol", c.SecurityLevel)
+		}
+	case gosnmp.AuthNoPriv:
+		if auth == gosnmp.NoAuth {
+			return fmt.Errorf("snmpv3: security_level %s requires an auth_protocol", c.SecurityLevel)
+		}
+		if priv != gosnmp.NoPriv {
+			return fmt.Errorf("snmpv3: security_level %s does not take a priv_protocol", c.SecurityLevel)
+		}
+	case gosnmp.AuthPriv:
+		if priv == gosnmp.NoPriv {
+			return fmt.Errorf("snmpv3: security_level %s requires a priv_protocol", c.SecurityLevel)
+		}
+	}
+	return nil
+}
+
+// apply switches the session to SNMPv3 with user-based security.
+// The config must have been validated.
+func (c *SNMPv3Config) apply(params *gosnmp.GoSNMP) {
+	auth, _ := c.authProtocol()
+	priv, _ := c.privProtocol()
+	flags, _ := c.securityLevel()
+
+	params.Version = gosnmp.Version3
+	params.Community = ""
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.MsgFlags = flags
+	params.SecurityParameters = &gosnmp.UsmSecurityParameters{
+		UserName:                 c.SecurityName,
+		AuthenticationProtocol:   auth,
+		AuthenticationPassphrase: c.AuthPassphrase,
+		PrivacyProtocol:          priv,
+		PrivacyPassphrase:        c.PrivPassphrase,
+	}
+}
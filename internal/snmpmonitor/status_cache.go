@@ -0,0 +1,78 @@
+package snmpmonitor
+
+import (
+	"time"
+)
+
+// collectStatus reads the current status and values of every configured device from its poller
+func (ws *WebServer) collectStatus() []DeviceStatus {
+	config := ws.agent.GetConfig()
+	devices := make([]DeviceStatus, len(config.Devices))
+	conflicts := config.hubConflicts()
+
+	for i, device := range config.Devices {
+		// Get actual status and metrics from poller
+		if device.Disabled {
+			devices[i] = DeviceStatus{
+				Name:     device.Name,
+				IP:       device.IP,
+				Status:   "Disabled",
+				Disabled: true,
+				Metrics:  make(map[string]float64),
+			}
+			continue
+		}
+
+		deviceStatus, metrics := ws.agent.GetPollerStatus(device.Name)
+
+		devices[i] = DeviceStatus{
+			Name:    device.Name,
+			IP:      device.IP,
+			Status:  deviceStatus,
+			Metrics: metrics,
+			Groups:  groupMetrics(device, metrics),
+
+			HubConflicts: conflicts[device.Name],
+		}
+		if poller, ok := ws.agent.GetPoller(device.Name); ok {
+			info := poller.GetPollInfo()
+			devices[i].Poll = &info
+			if labels := poller.GetLastLabels(); len(labels) > 0 {
+				devices[i].Labels = labels
+			}
+		}
+	}
+	return devices
+}
+
+// deviceStatuses returns the cached status snapshot when caching is enabled,
+// otherwise it collects the status from the pollers
+func (ws *WebServer) deviceStatuses() []DeviceStatus {
+	ws.statusMu.RLock()
+	devices := ws.statusSnapshot
+	ws.statusMu.RUnlock()
+
+	if devices == nil {
+		return ws.collectStatus()
+	}
+	return devices
+}
+
+// refreshStatus rebuilds the status snapshot every interval until stop is closed
+func (ws *WebServer) refreshStatus(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		devices := ws.collectStatus()
+		ws.statusMu.Lock()
+		ws.statusSnapshot = devices
+		ws.statusMu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
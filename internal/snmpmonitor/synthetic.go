@@ -0,0 +1,112 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Aggregate functions for synthetic metrics
+const (
+	AggregateMax = "max"
+	AggregateMin = "min"
+	AggregateAvg = "avg"
+	AggregateSum = "sum"
+)
+
+// SyntheticMetric defines a metric computed from other metrics on the same device
+type SyntheticMetric struct {
+	Name string `json:"name"`
+	// Func is the aggregate to compute: max, min, avg or sum
+	Func string `json:"func"`
+	// Match is a regular expression over metric keys selecting the inputs
+	Match       string `json:"match"`
+	Unit        string `json:"unit,omitempty"`
+	Category    string `json:"category,omitempty"`
+	SensorGroup string `json:"sensor_group,omitempty"`
+}
+
+// compile validates the synthetic metric and compiles its match pattern
+func (s *SyntheticMetric) compile() (*regexp.Regexp, error) {
+	switch s.Func {
+	case AggregateMax, AggregateMin, AggregateAvg, AggregateSum:
+	default:
+		return nil, fmt.Errorf("invalid func %q: must be max, min, avg or sum", s.Func)
+	}
+	if s.Match == "" {
+		return nil, fmt.Errorf("match is required")
+	}
+	re, err := regexp.Compile(s.Match)
+	if err != nil {
+		return nil, fmt.Errorf("invalid match: %w", err)
+	}
+	return re, nil
+}
+
+// validateSynthetic checks the device's synthetic metrics
+func (d *DeviceConfig) validateSynthetic() error {
+	for key, synthetic := range d.Synthetic {
+		if _, exists := d.Metrics[key]; exists {
+			return fmt.Errorf("synthetic metric '%s' has the same key as a polled metric", key)
+		}
+		if _, err := synthetic.compile(); err != nil {
+			return fmt.Errorf("synthetic metric '%s': %w", key, err)
+		}
+	}
+	return nil
+}
+
+// computeSynthetic adds the device's synthetic metrics to metrics, computed from
+// the polled values already in it. A synthetic metric with no matching inputs is skipped.
+func (p *Poller) computeSynthetic(metrics map[string]MetricValue) {
+	keys := make([]string, 0, len(metrics))
+	for key := range metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for name, synthetic := range p.device.Synthetic {
+		re := p.synthetic[name]
+		if re == nil {
+			continue
+		}
+
+		var result float64
+		count := 0
+		for _, key := range keys {
+			if !re.MatchString(key) {
+				continue
+			}
+			value := metrics[key].Value
+			switch {
+			case count == 0:
+				result = value
+			case synthetic.Func == AggregateMax && value > result:
+				result = value
+			case synthetic.Func == AggregateMin && value < result:
+				result = value
+			case synthetic.Func == AggregateAvg, synthetic.Func == AggregateSum:
+				result += value
+			}
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		if synthetic.Func == AggregateAvg {
+			result /= float64(count)
+		}
+
+		p.mu.Lock()
+		p.lastValues[name] = result
+		p.mu.Unlock()
+
+		metrics[name] = MetricValue{
+			Name:     synthetic.Name,
+			Value:    result,
+			Unit:     synthetic.Unit,
+			Category: synthetic.Category,
+			Group:    synthetic.SensorGroup,
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package snmpmonitor
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rounding modes for MetricConfig.RoundMode
+const (
+	RoundNearest = "nearest"
+	RoundFloor   = "floor"
+	RoundCeil    = "ceil"
+	RoundTrunc   = "trunc"
+)
+
+// Scale modes for MetricConfig.ScaleMode
+const (
+	ScaleMultiply = "multiply"
+	ScaleDivide   = "divide"
+)
+
+// transformValue applies the metric's scale and rounding to a raw SNMP value
+func transformValue(raw float64, metric MetricConfig) float64 {
+	return roundValue(metric.applyScale(raw), metric)
+}
+
+// applyScale multiplies or divides a value by the metric's scale according to its
+// scale_mode. A scale of 0 is treated as 1, so an unset scale leaves values as they are.
+func (m *MetricConfig) applyScale(value float64) float64 {
+	if m.Scale == 0 {
+		return value
+	}
+	if m.ScaleMode == ScaleDivide {
+		return value / m.Scale
+	}
+	return value * m.Scale
+}
+
+// validateScale checks the metric's scale_mode
+func (m *MetricConfig) validateScale() error {
+	switch m.ScaleMode {
+	case "", ScaleMultiply, ScaleDivide:
+		return nil
+	}
+	return fmt.Errorf("invalid scale_mode %q: must be multiply or divide", m.ScaleMode)
+}
+
+// withDefaultRounding returns the metric with round_digits set to the default when the
+// metric sets none of round_mode, round_digits and round1
+func (m MetricConfig) withDefaultRounding(defaultDigits *int) MetricConfig {
+	if m.RoundMode == "" && m.RoundDigits == nil && !m.Round1 {
+		m.RoundDigits = defaultDigits
+	}
+	return m
+}
+
+// roundValue rounds a value according to the metric's round_mode and round_digits, with
+// round1 standing for round_digits 1. Values are left untouched when none is configured
+// or round_digits is -1.
+func roundValue(value float64, metric MetricConfig) float64 {
+	if metric.RoundMode == "" && metric.RoundDigits == nil && !metric.Round1 {
+		return value
+	}
+
+	digits := 0
+	if metric.RoundDigits != nil {
+		digits = *metric.RoundDigits
+	} else if metric.Round1 {
+		digits = 1
+	}
+	if digits < 0 {
+		return value
+	}
+	pow := math.Pow(10, float64(digits))
+
+	var round func(float64) float64
+	switch metric.RoundMode {
+	case RoundFloor:
+		round = math.Floor
+	case RoundCeil:
+		round = math.Ceil
+	case RoundTrunc:
+		round = math.Trunc
+	default:
+		round = math.Round
+	}
+	return round(value*pow) / pow
+}
+
+// validateRounding checks the metric's rounding settings
+func (m *MetricConfig) validateRounding() error {
+	switch m.RoundMode {
+	case "", RoundNearest, RoundFloor, RoundCeil, RoundTrunc:
+	default:
+		return fmt.Errorf("invalid round_mode %q: must be nearest, floor, ceil or trunc", m.RoundMode)
+	}
+	return validateRoundDigits(m.RoundDigits)
+}
+
+// validateRoundDigits checks a round_digits setting, which may be unset
+func validateRoundDigits(digits *int) error {
+	if digits != nil && (*digits < -1 || *digits > 10) {
+		return fmt.Errorf("round_digits must be between -1 (no rounding) and 10")
+	}
+	return nil
+}
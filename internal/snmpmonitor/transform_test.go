@@ -0,0 +1,77 @@
+package snmpmonitor
+
+import "testing"
+
+func TestTransformValueScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric MetricConfig
+		raw    float64
+		want   float64
+	}{
+		{"unset scale", MetricConfig{}, 42, 42},
+		{"zero scale multiply", MetricConfig{Scale: 0, ScaleMode: ScaleMultiply}, 42, 42},
+		{"zero scale divide", MetricConfig{Scale: 0, ScaleMode: ScaleDivide}, 42, 42},
+		{"default mode multiplies", MetricConfig{Scale: 0.1}, 250, 25},
+		{"multiply", MetricConfig{Scale: 1000, ScaleMode: ScaleMultiply}, 1.5, 1500},
+		{"divide", MetricConfig{Scale: 10, ScaleMode: ScaleDivide}, 255, 25.5},
+		{"negative scale", MetricConfig{Scale: -1}, 5, -5},
+		{"divide then round", MetricConfig{Scale: 3, ScaleMode: ScaleDivide, RoundMode: RoundNearest}, 10, 3},
+	}
+	for _, tt := range tests {
+		if got := transformValue(tt.raw, tt.metric); got != tt.want {
+			t.Errorf("%s: transformValue(%g) = %g, want %g", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateScaleMode(t *testing.T) {
+	for _, mode := range []string{"", ScaleMultiply, ScaleDivide} {
+		metric := MetricConfig{ScaleMode: mode}
+		if err := metric.validateScale(); err != nil {
+			t.Errorf("scale_mode %q: unexpected error %v", mode, err)
+		}
+	}
+	metric := MetricConfig{ScaleMode: "times"}
+	if err := metric.validateScale(); err == nil {
+		t.Error("scale_mode \"times\": expected an error")
+	}
+}
+
+func TestTransformValueZeroScaleIsIdentity(t *testing.T) {
+	// A scale of 0 means "not set" in every mode, so it must give the same result as 1
+	for _, mode := range []string{"", ScaleMultiply, ScaleDivide} {
+		for _, raw := range []float64{0, 1, -7.5, 2500, 1e9} {
+			unset := transformValue(raw, MetricConfig{Scale: 0, ScaleMode: mode})
+			one := transformValue(raw, MetricConfig{Scale: 1, ScaleMode: mode})
+			if unset != one || unset != raw {
+				t.Errorf("scale_mode %q, raw %g: scale 0 gave %g, scale 1 gave %g", mode, raw, unset, one)
+			}
+		}
+	}
+}
+
+func TestRoundDigits(t *testing.T) {
+	digits := func(n int) *int { return &n }
+	tests := []struct {
+		name     string
+		metric   MetricConfig
+		defaults *int
+		want     float64
+	}{
+		{"no rounding", MetricConfig{}, nil, 1013.256},
+		{"round_digits 2", MetricConfig{RoundDigits: digits(2)}, nil, 1013.26},
+		{"round_digits -1", MetricConfig{RoundDigits: digits(-1), RoundMode: RoundFloor}, nil, 1013.256},
+		{"round1 alias", MetricConfig{Round1: true}, nil, 1013.3},
+		{"round_digits wins over round1", MetricConfig{Round1: true, RoundDigits: digits(0)}, nil, 1013},
+		{"default applies", MetricConfig{}, digits(2), 1013.26},
+		{"default -1", MetricConfig{}, digits(-1), 1013.256},
+		{"own round1 beats default", MetricConfig{Round1: true}, digits(2), 1013.3},
+		{"own round_mode beats default", MetricConfig{RoundMode: RoundFloor}, digits(2), 1013},
+	}
+	for _, tt := range tests {
+		if got := transformValue(1013.256, tt.metric.withDefaultRounding(tt.defaults)); got != tt.want {
+			t.Errorf("%s: got %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
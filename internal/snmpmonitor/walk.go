@@ -0,0 +1,162 @@
+package snmpmonitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// defaultMaxWalkRows caps the rows read from a walked table when max_rows is not set
+const defaultMaxWalkRows = 100
+
+// errWalkLimit stops a walk once the metric's row limit is reached
+var errWalkLimit = errors.New("walk row limit reached")
+
+// GetMaxRows returns the maximum number of table rows read for a walk metric
+func (m *MetricConfig) GetMaxRows() int {
+	if m.MaxRows <= 0 {
+		return defaultMaxWalkRows
+	}
+	return m.MaxRows
+}
+
+// validateWalk checks the metric's walk settings
+func (m *MetricConfig) validateWalk() error {
+	if m.MaxRows < 0 {
+		return fmt.Errorf("max_rows must not be negative")
+	}
+	if m.MaxRows > 0 && !m.Walk {
+		return fmt.Errorf("max_rows requires walk")
+	}
+	if m.LabelOID != "" {
+		if !m.Walk {
+			return fmt.Errorf("label_oid requires walk")
+		}
+		if !oidPattern.MatchString(m.LabelOID) {
+			return fmt.Errorf("invalid label_oid %q", m.LabelOID)
+		}
+	}
+	return nil
+}
+
+// walk reads the table under a walk metric's OID and adds one metric per row to metrics,
+// keyed <key>.<index> and named "<name> <index>". With label_oid the row's value in that
+// column replaces the index in the name. At most max_rows rows are read.
+func (p *Poller) walk(params *gosnmp.GoSNMP, key string, metricConfig MetricConfig, now time.Time, metrics map[string]MetricValue) error {
+	maxRows := metricConfig.GetMaxRows()
+
+	values, err := walkColumn(params, metricConfig.OID, maxRows)
+	if errors.Is(err, errWalkLimit) {
+		log.Printf("Walk of %s on device %s stopped at max_rows (%d)", key, p.device.Name, maxRows)
+	} else if err != nil {
+		return err
+	}
+
+	var labels []gosnmp.SnmpPDU
+	if metricConfig.LabelOID != "" {
+		labels, err = walkColumn(params, metricConfig.LabelOID, maxRows)
+		if err != nil && !errors.Is(err, errWalkLimit) {
+			log.Printf("Walk of label_oid %s on device %s failed, naming rows by index: %v", metricConfig.LabelOID, p.device.Name, err)
+		}
+	}
+
+	for _, row := range joinRows(metricConfig.OID, values, metricConfig.LabelOID, labels) {
+		name := row.label
+		if name == "" {
+			name = row.index
+		}
+		rowConfig := metricConfig
+		rowConfig.Name = metricConfig.Name + " " + name
+		rowKey := key + "." + row.index
+		if metric, ok := p.buildMetric(key, rowKey, rowConfig, row.variable, now); ok {
+			metrics[rowKey] = metric
+		}
+	}
+	return nil
+}
+
+// walkColumn reads at most maxRows rows of the table column under oid. When the limit is
+// reached the rows read so far are returned with errWalkLimit.
+func walkColumn(params *gosnmp.GoSNMP, oid string, maxRows int) ([]gosnmp.SnmpPDU, error) {
+	var rows []gosnmp.SnmpPDU
+	err := params.BulkWalk(oid, func(variable gosnmp.SnmpPDU) error {
+		if len(rows) >= maxRows {
+			return errWalkLimit
+		}
+		rows = append(rows, variable)
+		return nil
+	})
+	return rows, err
+}
+
+// tableRow is one row of a walked table column and its label
+type tableRow struct {
+	index    string
+	variable gosnmp.SnmpPDU
+	label    string
+}
+
+// tableIndex returns the row index of an OID in a table column: the arcs after the column OID
+func tableIndex(column, oid string) (string, bool) {
+	index, ok := strings.CutPrefix(strings.TrimPrefix(oid, "."), strings.TrimPrefix(column, ".")+".")
+	if !ok || index == "" {
+		return "", false
+	}
+	return index, true
+}
+
+// joinRows matches the rows of a value column with the rows of a label column by index
+// rather than by position, so a row missing from either column of a sparse table doesn't
+// shift the labels of the rows after it. Value rows without a label get an empty label,
+// and labels without a value are dropped.
+func joinRows(valueColumn string, values []gosnmp.SnmpPDU, labelColumn string, labels []gosnmp.SnmpPDU) []tableRow {
+	labelsByIndex := make(map[string]string, len(labels))
+	for _, variable := range labels {
+		if index, ok := tableIndex(labelColumn, variable.Name); ok {
+			labelsByIndex[index] = labelString(variable.Value)
+		}
+	}
+
+	rows := make([]tableRow, 0, len(values))
+	for _, variable := range values {
+		index, ok := tableIndex(valueColumn, variable.Name)
+		if !ok {
+			continue
+		}
+		rows = append(rows, tableRow{index: index, variable: variable, label: labelsByIndex[index]})
+	}
+	return rows
+}
+
+// labelString converts a label column value to a string
+func labelString(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return strings.TrimSpace(string(v))
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// configKey returns the key of the configured metric a polled metric key belongs to,
+// stripping the row index from the keys of walked rows
+func (p *Poller) configKey(key string) string {
+	for k := key; ; {
+		if _, ok := p.device.Metrics[k]; ok {
+			return k
+		}
+		i := strings.LastIndexByte(k, '.')
+		if i < 0 {
+			return key
+		}
+		k = k[:i]
+	}
+}
@@ -0,0 +1,79 @@
+package snmpmonitor
+
+import (
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	ifHCInOctets = ".1.3.6.1.2.1.31.1.1.1.6"
+	ifName       = ".1.3.6.1.2.1.31.1.1.1.1"
+)
+
+func TestJoinRowsSparseTable(t *testing.T) {
+	// Interface 2 has no ifName and interface 4 has no counter
+	values := []gosnmp.SnmpPDU{
+		{Name: ifHCInOctets + ".1", Type: gosnmp.Counter64, Value: uint64(100)},
+		{Name: ifHCInOctets + ".2", Type: gosnmp.Counter64, Value: uint64(200)},
+		{Name: ifHCInOctets + ".3", Type: gosnmp.Counter64, Value: uint64(300)},
+	}
+	labels := []gosnmp.SnmpPDU{
+		{Name: ifName + ".1", Type: gosnmp.OctetString, Value: []byte("eth0")},
+		{Name: ifName + ".3", Type: gosnmp.OctetString, Value: []byte("eth2")},
+		{Name: ifName + ".4", Type: gosnmp.OctetString, Value: []byte("eth3")},
+	}
+
+	rows := joinRows(ifHCInOctets, values, ifName, labels)
+
+	want := []struct {
+		index string
+		label string
+		value uint64
+	}{
+		{"1", "eth0", 100},
+		{"2", "", 200},
+		{"3", "eth2", 300},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for i, w := range want {
+		row := rows[i]
+		if row.index != w.index || row.label != w.label || row.variable.Value != w.value {
+			t.Errorf("row %d: got index %q label %q value %v, want index %q label %q value %d",
+				i, row.index, row.label, row.variable.Value, w.index, w.label, w.value)
+		}
+	}
+}
+
+func TestJoinRowsWithoutLeadingDots(t *testing.T) {
+	values := []gosnmp.SnmpPDU{{Name: ".1.3.6.1.4.1.9.1.2.1.5", Value: 42}}
+	labels := []gosnmp.SnmpPDU{{Name: ".1.3.6.1.4.1.9.1.3.1.5", Value: "PSU"}}
+
+	rows := joinRows("1.3.6.1.4.1.9.1.2", values, "1.3.6.1.4.1.9.1.3", labels)
+	if len(rows) != 1 || rows[0].index != "1.5" || rows[0].label != "PSU" {
+		t.Fatalf("got %+v, want one row with index 1.5 and label PSU", rows)
+	}
+}
+
+func TestTableIndex(t *testing.T) {
+	tests := []struct {
+		column, oid string
+		index       string
+		ok          bool
+	}{
+		{ifName, ifName + ".7", "7", true},
+		{ifName, ifName + ".1.2.3", "1.2.3", true},
+		{ifName, ifName, "", false},
+		// a sibling column sharing the column's digits as a prefix is not a row
+		{ifName, ifName + "0.1", "", false},
+		{ifName, ifHCInOctets + ".1", "", false},
+	}
+	for _, tt := range tests {
+		index, ok := tableIndex(tt.column, tt.oid)
+		if index != tt.index || ok != tt.ok {
+			t.Errorf("tableIndex(%q, %q) = %q, %v, want %q, %v", tt.column, tt.oid, index, ok, tt.index, tt.ok)
+		}
+	}
+}
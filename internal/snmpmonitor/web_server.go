@@ -2,11 +2,23 @@ package snmpmonitor
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrygd/beszel"
 )
 
 // WebServer handles the web interface for configuration
@@ -14,6 +26,14 @@ type WebServer struct {
 	agent  *Agent
 	config *WebServerConfig
 	mux    *http.ServeMux
+
+	serverMu sync.Mutex
+	server   *http.Server
+
+	// statusSnapshot caches device statuses when status_cache_sec is set
+	statusMu       sync.RWMutex
+	statusSnapshot []DeviceStatus
+	statusStop     chan struct{}
 }
 
 // NewWebServer creates a new web server
@@ -36,19 +56,135 @@ func (ws *WebServer) setupRoutes() {
 	// API routes
 	ws.mux.HandleFunc("/api/config", ws.handleConfig)
 	ws.mux.HandleFunc("/api/devices", ws.handleDevices)
+	ws.mux.HandleFunc("/api/devices/discover", ws.handleDiscover)
+	ws.mux.HandleFunc("/api/device/metrics", ws.handleDeviceMetrics)
+	ws.mux.HandleFunc("/api/device/clone", ws.handleDeviceClone)
+	ws.mux.HandleFunc("/api/device/oid-stats", ws.handleDeviceOIDStats)
+	ws.mux.HandleFunc("/api/device/capabilities", ws.handleDeviceCapabilities)
+	ws.mux.HandleFunc("/api/device/stats", ws.handleDeviceStats)
+	ws.mux.HandleFunc("/api/oid/resolve", ws.handleOIDResolve)
+	ws.mux.HandleFunc("/api/metrics/bulk-update", ws.handleMetricsBulkUpdate)
 	ws.mux.HandleFunc("/api/status", ws.handleStatus)
+	ws.mux.HandleFunc("/api/internal/stats", ws.handleInternalStats)
+	ws.mux.HandleFunc("/api/version", ws.handleVersion)
 	ws.mux.HandleFunc("/api/hub/test", ws.handleHubTest)
+	ws.mux.HandleFunc("/metrics", ws.handlePrometheus)
+	ws.mux.HandleFunc("/healthz", ws.handleHealthz)
+	ws.mux.HandleFunc("/readyz", ws.handleReadyz)
 
 	// Web interface
 	ws.mux.HandleFunc("/", ws.handleIndex)
 }
 
-// Start starts the web server
-func (ws *WebServer) Start() error {
-	port := ws.config.Port
-	addr := fmt.Sprintf(":%d", port)
-	log.Printf("Web server listening on %s", addr)
-	return http.ListenAndServe(addr, ws.mux)
+// Listen binds the web server's address and loads its certificate, so that a port that is
+// already in use or a bad certificate is reported before the server starts serving
+func (ws *WebServer) Listen() (net.Listener, error) {
+	addr := ws.config.Addr()
+	var tlsConfig *tls.Config
+	if ws.config.UseTLS() {
+		// Certificates are served through the reloader so renewed files are picked up
+		reloader, err := newCertReloader(ws.config.TLSCertFile, ws.config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = &tls.Config{GetCertificate: reloader.GetCertificate}
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		log.Printf("Web server listening on %s (HTTPS)", addr)
+	} else {
+		log.Printf("Web server listening on %s", addr)
+	}
+
+	ws.serverMu.Lock()
+	ws.server = &http.Server{Addr: addr, Handler: ws.handler(), TLSConfig: tlsConfig}
+	if interval := ws.config.GetStatusCacheInterval(); interval > 0 {
+		ws.statusStop = make(chan struct{})
+		go ws.refreshStatus(interval, ws.statusStop)
+	}
+	ws.serverMu.Unlock()
+	return listener, nil
+}
+
+// Serve handles requests on a listener returned by Listen until the server is shut down
+func (ws *WebServer) Serve(listener net.Listener) error {
+	ws.serverMu.Lock()
+	server := ws.server
+	ws.serverMu.Unlock()
+
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the web server, waiting for active requests to finish until ctx is done
+func (ws *WebServer) Shutdown(ctx context.Context) error {
+	ws.serverMu.Lock()
+	server := ws.server
+	if ws.statusStop != nil {
+		close(ws.statusStop)
+		ws.statusStop = nil
+	}
+	ws.serverMu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// handler returns the mux wrapped in the configured middleware
+func (ws *WebServer) handler() http.Handler {
+	var h http.Handler = ws.mux
+	if ws.config.ReadOnly {
+		log.Println("Web server running in read-only mode, configuration changes are disabled")
+		h = ws.readOnly(h)
+	}
+	if ws.config.Auth != nil {
+		h = ws.requireAuth(h)
+	} else {
+		log.Println("WARNING: web server authentication is not configured, anyone who can reach it can read and change the configuration")
+	}
+	return h
+}
+
+// handleHealthz answers liveness probes: it succeeds whenever the web server is up
+func (ws *WebServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz answers readiness probes: it succeeds once a poller has produced a value
+// or a device is connected to the hub, and fails with 503 until then
+func (ws *WebServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !ws.agent.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready\n"))
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// readOnly rejects every request that could modify the configuration with 403
+func (ws *WebServer) readOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ws.sendJSONError(w, "Web server is in read-only mode", fmt.Errorf("%s %s is not allowed", r.Method, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // handleIndex serves the main web interface
@@ -210,6 +346,11 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        function formatValue(device, name, value) {
+            const label = device.labels && device.labels[name];
+            return label ? label + ' (' + value + ')' : value;
+        }
+
         function renderStatus(status) {
             const statusDiv = document.getElementById('statusInfo');
             let html = '<div class="device-list">';
@@ -226,13 +367,31 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                 html += '</div>';
                 html += '<div>Status: <strong>' + device.status + '</strong></div>';
                 html += '</div>';
+                if (device.poll && device.poll.last_error) {
+                    const code = device.poll.error_code ? ' [' + device.poll.error_code + ']' : '';
+                    html += '<div class="device-ip">Last error: ' + device.poll.last_error + code + ' (' + device.poll.consecutive_failures + ' consecutive failures)</div>';
+                }
                 
                 if (device.metrics) {
+                    const grouped = new Set();
+                    for (const [group, values] of Object.entries(device.groups || {})) {
+                        html += '<div class="metric-name">' + group + '</div>';
+                        html += '<div class="metric-grid">';
+                        for (const [name, value] of Object.entries(values)) {
+                            grouped.add(name);
+                            html += '<div class="metric">';
+                            html += '<div class="metric-name">' + name + '</div>';
+                            html += '<div class="metric-value">' + formatValue(device, name, value) + '</div>';
+                            html += '</div>';
+                        }
+                        html += '</div>';
+                    }
                     html += '<div class="metric-grid">';
                     for (const [name, value] of Object.entries(device.metrics)) {
+                        if (grouped.has(name)) continue;
                         html += '<div class="metric">';
                         html += '<div class="metric-name">' + name + '</div>';
-                        html += '<div class="metric-value">' + value + '</div>';
+                        html += '<div class="metric-value">' + formatValue(device, name, value) + '</div>';
                         html += '</div>';
                     }
                     html += '</div>';
@@ -251,7 +410,7 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                 const device = devices[i];
                 html += '<div class="device-item">';
                 html += '<div class="device-header">';
-                html += '<span class="device-name">' + device.name + '</span>';
+                html += '<span class="device-name">' + device.name + (device.disabled ? ' (disabled)' : '') + '</span>';
                 html += '<div>';
                 html += '<button class="btn btn-success" onclick="saveDevice(' + i + ')" style="margin-right: 10px;">Save Device</button>';
                 html += '<button class="btn btn-danger" onclick="removeDevice(' + i + ')">Remove</button>';
@@ -331,10 +490,6 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                     showStatus('IP address is required', 'error');
                     return;
                 }
-                if (!community.trim()) {
-                    showStatus('Community string is required', 'error');
-                    return;
-                }
                 if (isNaN(pollInterval) || pollInterval <= 0) {
                     showStatus('Poll interval must be a positive number', 'error');
                     return;
@@ -350,13 +505,14 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                 }
                 
                 // Update device in memory
-                devices[index] = {
+                // Settings without a form field (e.g. disabled, synthetic) are kept as they are
+                devices[index] = Object.assign({}, devices[index], {
                     name: name.trim(),
                     ip: ip.trim(),
                     community: community.trim(),
                     poll_interval_sec: pollInterval,
                     metrics: metrics
-                };
+                });
                 
                 // Save to server
                 const response = await fetch('/api/config', {
@@ -408,11 +564,6 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                         hasErrors = true;
                         continue;
                     }
-                    if (!community.trim()) {
-                        showStatus('Device ' + (i + 1) + ': community string is required', 'error');
-                        hasErrors = true;
-                        continue;
-                    }
                     if (isNaN(pollInterval) || pollInterval <= 0) {
                         showStatus('Device ' + (i + 1) + ': poll interval must be a positive number', 'error');
                         hasErrors = true;
@@ -429,13 +580,13 @@ func (ws *WebServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                         continue;
                     }
                     
-                    updatedDevices.push({
+                    updatedDevices.push(Object.assign({}, devices[i], {
                         name: name.trim(),
                         ip: ip.trim(),
                         community: community.trim(),
                         poll_interval_sec: pollInterval,
                         metrics: metrics
-                    });
+                    }));
                 }
                 
                 if (hasErrors) {
@@ -653,21 +804,39 @@ func (ws *WebServer) handleConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getConfig returns the current configuration
+// getConfig returns the current configuration. Secrets are redacted unless ?reveal=true
+// is passed to a writable web server that requires authentication.
 func (ws *WebServer) getConfig(w http.ResponseWriter, r *http.Request) {
 	// Combine config from JSON file and environment variables
 	config := ws.agent.GetConfig()
 	hubConfig := ws.agent.GetHubConfig()
 	webServerConfig := ws.agent.GetWebServerConfig()
 
-	combinedConfig := struct {
-		Hub       *HubConfig       `json:"hub"`
-		WebServer *WebServerConfig `json:"web_server"`
-		Devices   []DeviceConfig   `json:"devices"`
-	}{
+	reveal := r.URL.Query().Get("reveal") == "true"
+	if reveal && ws.config.ReadOnly {
+		ws.sendJSONError(w, "Secrets cannot be revealed", fmt.Errorf("web server is in read-only mode"), http.StatusForbidden)
+		return
+	}
+	if reveal && ws.config.Auth == nil {
+		ws.sendJSONError(w, "Secrets cannot be revealed", fmt.Errorf("web server has no auth configured"), http.StatusForbidden)
+		return
+	}
+	influxConfig, mqttConfig, devices := config.Influx, config.MQTT, config.Devices
+	if !reveal {
+		hubConfig = redactedHubConfig(hubConfig)
+		webServerConfig = redactedWebServerConfig(webServerConfig)
+		influxConfig = redactedInfluxConfig(influxConfig)
+		mqttConfig = redactedMQTTConfig(mqttConfig)
+		devices = redactedDevices(devices)
+	}
+
+	combinedConfig := configPayload{
 		Hub:       hubConfig,
 		WebServer: webServerConfig,
-		Devices:   config.Devices,
+		Defaults:  config.Defaults,
+		Devices:   devices,
+		Influx:    influxConfig,
+		MQTT:      mqttConfig,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -677,9 +846,9 @@ func (ws *WebServer) getConfig(w http.ResponseWriter, r *http.Request) {
 // updateConfig updates the configuration
 func (ws *WebServer) updateConfig(w http.ResponseWriter, r *http.Request) {
 	// Read the raw body first for JSON validation
-	body, err := ws.readRequestBody(r)
+	body, err := ws.readRequestBody(w, r)
 	if err != nil {
-		ws.sendJSONError(w, "Failed to read request body", err, http.StatusBadRequest)
+		ws.sendBodyError(w, err)
 		return
 	}
 
@@ -690,29 +859,42 @@ func (ws *WebServer) updateConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse the JSON
-	var updateData struct {
-		Hub       *HubConfig       `json:"hub"`
-		WebServer *WebServerConfig `json:"web_server"`
-		Devices   []DeviceConfig   `json:"devices"`
-	}
+	var updateData configPayload
 
 	if err := json.Unmarshal(body, &updateData); err != nil {
 		ws.sendJSONError(w, "Failed to parse configuration", err, http.StatusBadRequest)
 		return
 	}
 
+	// Masked secrets sent back from the form mean the secret is unchanged
+	restoreRedactedSecrets(updateData.Hub, ws.agent.GetHubConfig())
+	restoreRedactedAuth(updateData.WebServer, ws.agent.GetWebServerConfig())
+	restoreRedactedInflux(updateData.Influx, ws.agent.GetConfig().Influx)
+	restoreRedactedMQTT(updateData.MQTT, ws.agent.GetConfig().MQTT)
+	restoreRedactedDevices(updateData.Devices, ws.agent.GetConfig().Devices)
+
 	// Validate configuration structure
 	if err := ws.validateConfiguration(&updateData); err != nil {
 		ws.sendJSONError(w, "Configuration validation failed", err, http.StatusBadRequest)
 		return
 	}
 
-	// Create new config with devices
-	newConfig := &Config{
-		Devices: updateData.Devices,
+	// Start from the current configuration and replace only the sections that were sent, so
+	// saving the devices keeps the hub settings in the file and saving the hub keeps the devices.
+	// An empty devices array is sent as [] and removes all devices; a missing one keeps them.
+	newConfig := ws.agent.GetConfig().Clone()
+	if updateData.Devices != nil {
+		newConfig.Devices = updateData.Devices
+	}
+	if updateData.Defaults != nil {
+		newConfig.Defaults = updateData.Defaults
+	}
+	if updateData.Influx != nil {
+		newConfig.Influx = updateData.Influx
+	}
+	if updateData.MQTT != nil {
+		newConfig.MQTT = updateData.MQTT
 	}
-
-	// Add hub and web server config if provided
 	if updateData.Hub != nil {
 		newConfig.Hub = updateData.Hub
 	}
@@ -733,34 +915,397 @@ func (ws *WebServer) updateConfig(w http.ResponseWriter, r *http.Request) {
 
 // handleDevices handles device API requests
 func (ws *WebServer) handleDevices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "DELETE":
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			ws.sendJSONError(w, "Device name is required", fmt.Errorf("missing name parameter"), http.StatusBadRequest)
+			return
+		}
+		if err := ws.agent.RemoveDevice(name); errors.Is(err, ErrDeviceNotFound) {
+			ws.sendJSONError(w, "Device not found", fmt.Errorf("no device named %q", name), http.StatusNotFound)
+			return
+		} else if err != nil {
+			ws.sendJSONError(w, "Failed to remove device", err, http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ws.agent.GetConfig().Devices)
+	json.NewEncoder(w).Encode(redactedDevices(ws.agent.GetConfig().Devices))
 }
 
-// handleStatus returns the current status
-func (ws *WebServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+// handleDeviceMetrics returns or replaces the metric definitions of a single device
+func (ws *WebServer) handleDeviceMetrics(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		ws.sendJSONError(w, "Device name is required", fmt.Errorf("missing name parameter"), http.StatusBadRequest)
+		return
+	}
+
+	config := ws.agent.GetConfig()
+	index := config.FindDevice(name)
+	if index < 0 {
+		ws.sendJSONError(w, "Device not found", fmt.Errorf("no device named %q", name), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(config.Devices[index].Metrics)
+	case "PUT":
+		ws.updateDeviceMetrics(w, r, config, index)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// updateDeviceMetrics replaces the metrics of one device, leaving all other settings untouched
+func (ws *WebServer) updateDeviceMetrics(w http.ResponseWriter, r *http.Request, config *Config, index int) {
+	body, err := ws.readRequestBody(w, r)
+	if err != nil {
+		ws.sendBodyError(w, err)
+		return
+	}
+
+	var metrics map[string]MetricConfig
+	if err := json.Unmarshal(body, &metrics); err != nil {
+		ws.sendJSONError(w, "Failed to parse metrics", err, http.StatusBadRequest)
+		return
+	}
+
+	newConfig := config.Clone()
+	newConfig.Devices[index].Metrics = metrics
+
+	if err := ws.validateConfiguration(&configPayload{Devices: newConfig.Devices}); err != nil {
+		ws.sendJSONError(w, "Configuration validation failed", err, http.StatusBadRequest)
+		return
+	}
+
+	if err := ws.agent.UpdateConfig(newConfig); err != nil {
+		ws.sendJSONError(w, "Failed to update config", err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// handleDeviceClone adds a device that copies the settings and metrics of an existing one
+// under a new name and IP
+func (ws *WebServer) handleDeviceClone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ws.readRequestBody(w, r)
+	if err != nil {
+		ws.sendBodyError(w, err)
+		return
+	}
+
+	var request struct {
+		Source string `json:"source"`
+		Name   string `json:"name"`
+		IP     string `json:"ip"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		ws.sendJSONError(w, "Failed to parse request", err, http.StatusBadRequest)
+		return
+	}
+	if request.Source == "" || request.Name == "" || request.IP == "" {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("source, name and ip are required"), http.StatusBadRequest)
+		return
+	}
+
+	config := ws.agent.GetConfig()
+	index := config.FindDevice(request.Source)
+	if index < 0 {
+		ws.sendJSONError(w, "Device not found", fmt.Errorf("no device named %q", request.Source), http.StatusNotFound)
+		return
+	}
+	if config.FindDevice(request.Name) >= 0 {
+		ws.sendJSONError(w, "Device already exists", fmt.Errorf("a device named %q already exists", request.Name), http.StatusConflict)
+		return
+	}
+
+	newConfig := config.Clone()
+	device := newConfig.Devices[index]
+	device.Name = request.Name
+	device.IP = request.IP
+	for _, existing := range newConfig.Devices {
+		if existing.IP == device.IP && existing.GetPort() == device.GetPort() {
+			ws.sendJSONError(w, "Device already exists", fmt.Errorf("device %s already polls %s port %d", existing.Name, device.IP, device.GetPort()), http.StatusConflict)
+			return
+		}
+	}
+	newConfig.Devices = append(newConfig.Devices, device)
+
+	if err := ws.validateConfiguration(&configPayload{Devices: newConfig.Devices}); err != nil {
+		ws.sendJSONError(w, "Configuration validation failed", err, http.StatusBadRequest)
+		return
+	}
+	if err := ws.agent.UpdateConfig(newConfig); err != nil {
+		ws.sendJSONError(w, "Failed to update config", err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(redactedDevice(device))
+}
+
+// metricPatch holds the metric settings changed by a bulk update; nil fields are left as they are
+type metricPatch struct {
+	Scale     *float64 `json:"scale,omitempty"`
+	ScaleMode *string  `json:"scale_mode,omitempty"`
+	Unit      *string  `json:"unit,omitempty"`
+	Category  *string  `json:"category,omitempty"`
+}
+
+// apply changes the metric's settings that are set in the patch
+func (p *metricPatch) apply(metric *MetricConfig) {
+	if p.Scale != nil {
+		metric.Scale = *p.Scale
+	}
+	if p.ScaleMode != nil {
+		metric.ScaleMode = *p.ScaleMode
+	}
+	if p.Unit != nil {
+		metric.Unit = *p.Unit
+	}
+	if p.Category != nil {
+		metric.Category = *p.Category
+	}
+}
+
+// handleMetricsBulkUpdate applies one change to a metric across many devices. The metric is
+// selected by OID or by key; devices are selected by a regular expression matched against
+// their names, or all devices when none is given. The configuration is saved once.
+func (ws *WebServer) handleMetricsBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ws.readRequestBody(w, r)
+	if err != nil {
+		ws.sendBodyError(w, err)
+		return
+	}
+
+	var request struct {
+		OID    string `json:"oid"`
+		Metric string `json:"metric"`
+		// Devices is a regular expression matched against device names; empty selects all
+		Devices string      `json:"devices"`
+		Patch   metricPatch `json:"patch"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		ws.sendJSONError(w, "Failed to parse request", err, http.StatusBadRequest)
+		return
+	}
+	if (request.OID == "") == (request.Metric == "") {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("exactly one of oid and metric is required"), http.StatusBadRequest)
+		return
+	}
+	if request.Patch == (metricPatch{}) {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("patch must set scale, scale_mode, unit or category"), http.StatusBadRequest)
+		return
+	}
+	var devicePattern *regexp.Regexp
+	if request.Devices != "" {
+		if devicePattern, err = regexp.Compile(request.Devices); err != nil {
+			ws.sendJSONError(w, "Invalid devices pattern", err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	newConfig := ws.agent.GetConfig().Clone()
+	var updatedDevices []string
+	updatedMetrics := 0
+	for i := range newConfig.Devices {
+		device := &newConfig.Devices[i]
+		if devicePattern != nil && !devicePattern.MatchString(device.Name) {
+			continue
+		}
+		updated := false
+		for key, metric := range device.Metrics {
+			if request.Metric != "" && key != request.Metric {
+				continue
+			}
+			if request.OID != "" && strings.TrimPrefix(metric.OID, ".") != strings.TrimPrefix(request.OID, ".") {
+				continue
+			}
+			request.Patch.apply(&metric)
+			device.Metrics[key] = metric
+			updatedMetrics++
+			updated = true
+		}
+		if updated {
+			updatedDevices = append(updatedDevices, device.Name)
+		}
+	}
+
+	if updatedMetrics > 0 {
+		if err := ws.validateConfiguration(&configPayload{Devices: newConfig.Devices}); err != nil {
+			ws.sendJSONError(w, "Configuration validation failed", err, http.StatusBadRequest)
+			return
+		}
+		if err := ws.agent.UpdateConfig(newConfig); err != nil {
+			ws.sendJSONError(w, "Failed to update config", err, http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Bulk update changed %d metrics on %d devices", updatedMetrics, len(updatedDevices))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"devices_updated": len(updatedDevices),
+		"metrics_updated": updatedMetrics,
+		"devices":         updatedDevices,
+	})
+}
+
+// handleDeviceOIDStats returns the per-OID poll statistics of a device, or resets them on DELETE
+func (ws *WebServer) handleDeviceOIDStats(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	poller, ok := ws.agent.GetPoller(name)
+	if !ok {
+		ws.sendJSONError(w, "Device not found", fmt.Errorf("no running poller for device %q", name), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(poller.GetOIDStats())
+	case "DELETE":
+		poller.ResetOIDStats()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleOIDResolve reports which device and metric an OID polled from an IP maps to
+func (ws *WebServer) handleOIDResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ip := r.URL.Query().Get("ip")
+	oid := r.URL.Query().Get("oid")
+	if ip == "" || oid == "" {
+		ws.sendJSONError(w, "Missing parameters", fmt.Errorf("ip and oid are required"), http.StatusBadRequest)
+		return
+	}
+
+	type resolution struct {
+		Match     bool          `json:"match"`
+		Message   string        `json:"message,omitempty"`
+		Device    string        `json:"device,omitempty"`
+		MetricKey string        `json:"metric_key,omitempty"`
+		Metric    *MetricConfig `json:"metric,omitempty"`
+		// Exact is false when the OIDs only match after ignoring a leading dot,
+		// in which case polling won't pick the value up
+		Exact    bool `json:"exact,omitempty"`
+		Disabled bool `json:"disabled,omitempty"`
+	}
+
+	result := resolution{Message: "no match"}
 	config := ws.agent.GetConfig()
+	for _, device := range config.Devices {
+		if device.IP != ip {
+			continue
+		}
+		result.Message = "no metric with this OID on device " + device.Name
+		for key, metric := range device.Metrics {
+			if strings.TrimPrefix(metric.OID, ".") != strings.TrimPrefix(oid, ".") {
+				continue
+			}
+			result = resolution{
+				Match:     true,
+				Device:    device.Name,
+				MetricKey: key,
+				Metric:    &metric,
+				Exact:     metric.OID == oid,
+				Disabled:  device.Disabled,
+			}
+			break
+		}
+		if result.Match {
+			break
+		}
+	}
+	if result.Message == "no match" {
+		result.Message = "no device with IP " + ip
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
 
+// handleStatus returns the current status
+func (ws *WebServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	status := struct {
 		Devices []DeviceStatus `json:"devices"`
 	}{
-		Devices: make([]DeviceStatus, len(config.Devices)),
+		Devices: ws.deviceStatuses(),
 	}
 
-	for i, device := range config.Devices {
-		// Get actual status and metrics from poller
-		deviceStatus, metrics := ws.agent.GetPollerStatus(device.Name)
-
-		status.Devices[i] = DeviceStatus{
-			Name:    device.Name,
-			IP:      device.IP,
-			Status:  deviceStatus,
-			Metrics: metrics,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleInternalStats reports the collector's own load
+func (ws *WebServer) handleInternalStats(w http.ResponseWriter, r *http.Request) {
+	stats := struct {
+		Polls PollStats `json:"polls"`
+	}{
+		Polls: ws.agent.GetPollStats(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleVersion reports which build is running and for how long. The commit and build
+// time come from the VCS information Go embeds when building from a git checkout.
+func (ws *WebServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	version := struct {
+		Version       string    `json:"version"`
+		GoVersion     string    `json:"go_version"`
+		Commit        string    `json:"commit,omitempty"`
+		BuildTime     string    `json:"build_time,omitempty"`
+		StartedAt     time.Time `json:"started_at"`
+		UptimeSeconds int64     `json:"uptime_seconds"`
+	}{
+		Version:       beszel.Version,
+		GoVersion:     runtime.Version(),
+		StartedAt:     ws.agent.startedAt,
+		UptimeSeconds: int64(time.Since(ws.agent.startedAt).Seconds()),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				version.Commit = setting.Value
+			case "vcs.time":
+				version.BuildTime = setting.Value
+			}
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(version)
 }
 
 // handleHubTest tests the hub connection
@@ -771,27 +1316,70 @@ func (ws *WebServer) handleHubTest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	hubConfig := ws.agent.GetHubConfig()
-	_, err := NewHubClient(*hubConfig)
+	client, err := NewHubClient(*hubConfig)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create hub client: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if err := client.verifyReachable(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
 
-	// Connection test passed (client creation succeeded)
 	w.WriteHeader(http.StatusOK)
 }
 
+// configPayload is the configuration shape exchanged with the web interface
+type configPayload struct {
+	Hub       *HubConfig       `json:"hub"`
+	WebServer *WebServerConfig `json:"web_server"`
+	Defaults  *DefaultsConfig  `json:"defaults,omitempty"`
+	Devices   []DeviceConfig   `json:"devices"`
+	Influx    *InfluxConfig    `json:"influx,omitempty"`
+	MQTT      *MQTTConfig      `json:"mqtt,omitempty"`
+}
+
 // DeviceStatus represents the status of a device
 type DeviceStatus struct {
 	Name    string             `json:"name"`
 	IP      string             `json:"ip"`
 	Status  string             `json:"status"`
 	Metrics map[string]float64 `json:"metrics"`
+	// Disabled is set for devices kept in the configuration but not polled
+	Disabled bool `json:"disabled,omitempty"`
+	// Poll holds the last poll time and error of a running poller
+	Poll *PollInfo `json:"poll,omitempty"`
+	// Groups nests the values of metrics with a sensor_group under the group name
+	Groups map[string]map[string]float64 `json:"groups,omitempty"`
+	// Labels holds the enum_map label of metrics whose value has one
+	Labels map[string]string `json:"labels,omitempty"`
+	// HubConflicts names the other devices this one shares a hub entry with
+	HubConflicts []string `json:"hub_conflicts,omitempty"`
 }
 
-// readRequestBody reads and returns the request body
-func (ws *WebServer) readRequestBody(r *http.Request) ([]byte, error) {
-	body, err := io.ReadAll(r.Body)
+// groupMetrics nests metric values by their configured sensor group.
+// It returns nil if none of the device's metrics belong to a group.
+func groupMetrics(device DeviceConfig, values map[string]float64) map[string]map[string]float64 {
+	var groups map[string]map[string]float64
+	for key, value := range values {
+		group := device.Metrics[key].SensorGroup
+		if group == "" {
+			continue
+		}
+		if groups == nil {
+			groups = make(map[string]map[string]float64)
+		}
+		if groups[group] == nil {
+			groups[group] = make(map[string]float64)
+		}
+		groups[group][key] = value
+	}
+	return groups
+}
+
+// readRequestBody reads and returns the request body, failing once it exceeds max_body_bytes
+func (ws *WebServer) readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ws.config.GetMaxBodyBytes()))
 	if err != nil {
 		return nil, err
 	}
@@ -800,6 +1388,16 @@ func (ws *WebServer) readRequestBody(r *http.Request) ([]byte, error) {
 	return body, nil
 }
 
+// sendBodyError reports a request body that could not be read, with 413 if it was too large
+func (ws *WebServer) sendBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ws.sendJSONError(w, "Request body too large", fmt.Errorf("body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	ws.sendJSONError(w, "Failed to read request body", err, http.StatusBadRequest)
+}
+
 // sendJSONError sends a JSON error response
 func (ws *WebServer) sendJSONError(w http.ResponseWriter, message string, err error, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -813,11 +1411,12 @@ func (ws *WebServer) sendJSONError(w http.ResponseWriter, message string, err er
 }
 
 // validateConfiguration validates the configuration structure
-func (ws *WebServer) validateConfiguration(config *struct {
-	Hub       *HubConfig       `json:"hub"`
-	WebServer *WebServerConfig `json:"web_server"`
-	Devices   []DeviceConfig   `json:"devices"`
-}) error {
+func (ws *WebServer) validateConfiguration(config *configPayload) error {
+	defaults := ws.agent.GetConfig().GetDefaults()
+	if config.Defaults != nil {
+		defaults = *config.Defaults
+	}
+
 	// Validate devices
 	for i, device := range config.Devices {
 		if device.Name == "" {
@@ -826,12 +1425,15 @@ func (ws *WebServer) validateConfiguration(config *struct {
 		if device.IP == "" {
 			return fmt.Errorf("device %d: IP address is required", i)
 		}
-		if device.Community == "" {
+		if device.SNMPv3 == nil && len(device.mergeCommunities(defaults.Communities)) == 0 {
 			return fmt.Errorf("device %d: community string is required", i)
 		}
 		if device.PollInterval <= 0 {
 			return fmt.Errorf("device %d: poll interval must be greater than 0", i)
 		}
+		if device.Port < 0 || device.Port > 65535 {
+			return fmt.Errorf("device %d: port must be between 1 and 65535", i)
+		}
 
 		// Validate metrics
 		for metricName, metric := range device.Metrics {
@@ -865,6 +1467,14 @@ func (ws *WebServer) validateConfiguration(config *struct {
 		if config.WebServer.Port <= 0 || config.WebServer.Port > 65535 {
 			return fmt.Errorf("web server port must be between 1 and 65535")
 		}
+		if err := config.WebServer.validateTLS(); err != nil {
+			return err
+		}
+		if config.WebServer.Auth != nil {
+			if err := config.WebServer.Auth.validate(); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,123 @@
+package snmpmonitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const partialUpdateConfig = `{
+  "hub": {"url": "http://127.0.0.1:8090", "token": "hub-token", "key": "hub-key"},
+  "web_server": {"port": 6700, "auth": {"token": "api-token"}},
+  "devices": [
+    {"name": "ups", "ip": "127.0.0.1", "community": "public", "poll_interval_sec": 30, "disabled": true,
+     "metrics": {"load": {"oid": ".1.3.6.1.4.1.318.1.1.1.4.2.3.0", "name": "Load", "unit": "%", "category": "load", "scale": 1}}}
+  ]
+}`
+
+// postConfig sends a configuration update to the web server and fails the test unless it succeeds
+func postConfig(t *testing.T, ws *WebServer, body string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ws.handleConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/config %s: got %d: %s", body, rec.Code, rec.Body)
+	}
+}
+
+func TestUpdateConfigKeepsSectionsNotSent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(partialUpdateConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	agent, err := NewAgent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	// The device form posts only the devices
+	postConfig(t, agent.webServer, `{"devices": [
+		{"name": "ups", "ip": "127.0.0.2", "community": "public", "poll_interval_sec": 60, "disabled": true,
+		 "metrics": {"load": {"oid": ".1.3.6.1.4.1.318.1.1.1.4.2.3.0", "name": "Load", "unit": "%", "category": "load", "scale": 1}}}
+	]}`)
+
+	config, _, webServer, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Hub == nil || config.Hub.URL != "http://127.0.0.1:8090" || config.Hub.Token != "hub-token" {
+		t.Errorf("saving the devices lost the hub settings: %+v", config.Hub)
+	}
+	if webServer.Port != 6700 || webServer.Auth == nil || webServer.Auth.Token != "api-token" {
+		t.Errorf("saving the devices lost the web server settings: %+v", webServer)
+	}
+	if len(config.Devices) != 1 || config.Devices[0].IP != "127.0.0.2" {
+		t.Errorf("device update not saved: %+v", config.Devices)
+	}
+
+	// The hub form posts only the hub settings
+	postConfig(t, agent.webServer, `{"hub": {"url": "http://127.0.0.1:8091", "token": "new-token", "key": "hub-key"}}`)
+
+	config, _, webServer, err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Devices) != 1 || config.Devices[0].Name != "ups" {
+		t.Errorf("saving the hub settings lost the devices: %+v", config.Devices)
+	}
+	if config.Hub == nil || config.Hub.URL != "http://127.0.0.1:8091" {
+		t.Errorf("hub update not saved: %+v", config.Hub)
+	}
+	if webServer.Port != 6700 {
+		t.Errorf("saving the hub settings lost the web server port: %d", webServer.Port)
+	}
+}
+
+func TestConfigRedactsSNMPv3Passphrases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+	"hub": {"url": "http://127.0.0.1:8090", "token": "hub-token", "key": "hub-key"},
+	"devices": [
+		{"name": "switch", "ip": "127.0.0.1", "poll_interval_sec": 30, "disabled": true,
+		 "snmpv3": {"security_name": "monitor", "auth_protocol": "SHA", "auth_passphrase": "auth-secret-1234",
+		            "priv_protocol": "AES", "priv_passphrase": "priv-secret-5678"},
+		 "metrics": {"temp": {"oid": ".1.3.6.1.4.1.9.9.13.1.3.1.3.1", "name": "Temp", "unit": "C", "category": "temperature", "scale": 1}}}
+	]}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	agent, err := NewAgent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Stop()
+
+	rec := httptest.NewRecorder()
+	agent.webServer.handleConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "auth-secret") || strings.Contains(body, "priv-secret") {
+		t.Errorf("GET /api/config leaked an SNMPv3 passphrase: %s", body)
+	}
+
+	// Without web auth, reveal is refused
+	rec = httptest.NewRecorder()
+	agent.webServer.handleConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config?reveal=true", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("reveal without auth: got %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// Saving the redacted configuration back keeps the stored passphrases
+	postConfig(t, agent.webServer, body)
+	saved, _, _, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snmpv3 := saved.Devices[0].SNMPv3
+	if snmpv3 == nil || snmpv3.AuthPassphrase != "auth-secret-1234" || snmpv3.PrivPassphrase != "priv-secret-5678" {
+		t.Errorf("round trip overwrote the SNMPv3 passphrases: %+v", snmpv3)
+	}
+}